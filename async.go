@@ -0,0 +1,159 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// Prestarter is implemented by content that can start its work before it
+// is emitted, e.g. Future. BounT.Emit and BounT.EmitCtx prestart all bound
+// content before the first byte is written. This is done once by the
+// outermost BounT, nested BounTs rely on it. Content that wraps other
+// content should implement Prestarter to pass the call on, see Prestart.
+type Prestarter interface {
+	Prestart(ctx context.Context)
+}
+
+// prestartedKey marks a context of an emit that already prestarted its
+// content.
+type prestartedKey struct{}
+
+// Prestart calls c.Prestart if c is a Prestarter.
+func Prestart(ctx context.Context, c Content) {
+	if p, ok := c.(Prestarter); ok {
+		p.Prestart(ctx)
+	}
+}
+
+// Prestart prestarts all bound content of bt, including the content of
+// nested BounTs.
+func (bt *BounT) Prestart(ctx context.Context) {
+	for _, f := range bt.fill {
+		if f != nil {
+			Prestart(ctx, f)
+		}
+	}
+}
+
+// Pool limits the number of Futures that compute their content
+// concurrently.
+type Pool struct {
+	sem chan struct{}
+}
+
+// NewPool creates a pool that computes at most parallel Futures at the
+// same time. With parallel < 1 the number is not limited.
+func NewPool(parallel int) *Pool {
+	if parallel < 1 {
+		return &Pool{}
+	}
+	return &Pool{sem: make(chan struct{}, parallel)}
+}
+
+type poolKey struct{}
+
+// Async returns a Future for c that is started when the BounT it is bound
+// to is emitted or when it is emitted itself.
+func (p *Pool) Async(c Content) *Future {
+	return &Future{pool: p, cnt: c, done: make(chan struct{})}
+}
+
+// Go returns a Future for c that is already started with ctx.
+func (p *Pool) Go(ctx context.Context, c Content) *Future {
+	f := p.Async(c)
+	f.Prestart(ctx)
+	return f
+}
+
+// Future is content that is computed into a buffer in its own goroutine.
+// Emitting a Future waits for the computation to finish and writes the
+// buffer. This way independent expensive content of a template is computed
+// concurrently while the output is still written in template order. An
+// EmitError from the computation is raised when the Future is emitted.
+// Futures are computed only once, even if emitted several times. Flush
+// points in the content of a Future have no effect.
+//
+// Content of a Future may itself contain Futures of the same pool only if
+// they are emitted with EmitCtx. Otherwise a limited pool may dead-lock.
+type Future struct {
+	pool  *Pool
+	cnt   Content
+	start sync.Once
+	done  chan struct{}
+	buf   bytes.Buffer
+	err   error
+	rek   interface{}
+}
+
+// Prestart starts the computation of f with ctx unless it is already
+// started.
+func (f *Future) Prestart(ctx context.Context) {
+	f.start.Do(func() { go f.run(ctx) })
+}
+
+func (f *Future) run(ctx context.Context) {
+	defer close(f.done)
+	if sem := f.pool.sem; sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			f.err = ctx.Err()
+			return
+		}
+	}
+	defer func() {
+		if rek := recover(); rek != nil {
+			if ee, ok := rek.(EmitError); ok {
+				f.err = ee.Err
+			} else {
+				f.rek = rek
+			}
+		}
+	}()
+	ctx = context.WithValue(ctx, prestartedKey{}, nil)
+	EmitCtx(context.WithValue(ctx, poolKey{}, f.pool), f.cnt, &f.buf)
+}
+
+func (f *Future) Emit(wr io.Writer) int {
+	return f.EmitCtx(context.Background(), wr)
+}
+
+// EmitCtx waits for the computation of f and writes the result. It panics
+// with an EmitError if ctx is done before.
+func (f *Future) EmitCtx(ctx context.Context, wr io.Writer) int {
+	f.Prestart(ctx)
+	if err := f.wait(ctx); err != nil {
+		panic(EmitError{0, err})
+	}
+	switch {
+	case f.rek != nil:
+		panic(f.rek)
+	case f.err != nil:
+		panic(EmitError{0, f.err})
+	}
+	return Data(f.buf.Bytes()).Emit(wr)
+}
+
+func (f *Future) wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	if sem := f.pool.sem; sem != nil && ctx.Value(poolKey{}) == f.pool {
+		// We run in a Future of the same pool: Give our slot to f
+		<-sem
+		defer func() { sem <- struct{}{} }()
+	}
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
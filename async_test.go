@@ -0,0 +1,169 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ExamplePool_Async() {
+	pool := NewPool(4)
+	tmpl := NewTemplate("dashboard").Ph("a").AddStr(", ").Ph("b").AddStr("\n")
+	bt := tmpl.NewBounT(nil)
+	bt.BindName("a", pool.Async(Generator(func(wr io.Writer) int {
+		time.Sleep(20 * time.Millisecond)
+		return Print{V: "slow"}.Emit(wr)
+	})))
+	bt.BindName("b", pool.Async(Print{V: "fast"}))
+	bt.Emit(os.Stdout)
+	// Output:
+	// slow, fast
+}
+
+func TestFuture_concurrent(t *testing.T) {
+	pool := NewPool(2)
+	bSignal := make(chan struct{})
+	tmpl := NewTemplate("").Ph("a").AddStr("|").Ph("b")
+	bt := tmpl.NewBounT(nil)
+	bt.BindName("a", pool.Async(Generator(func(wr io.Writer) int {
+		select {
+		case <-bSignal:
+		case <-time.After(5 * time.Second):
+			panic(EmitError{Err: errors.New("b not computed concurrently")})
+		}
+		return Print{V: "A"}.Emit(wr)
+	})))
+	bt.BindName("b", pool.Async(Generator(func(wr io.Writer) int {
+		close(bSignal)
+		return Print{V: "B"}.Emit(wr)
+	})))
+	var buf bytes.Buffer
+	if _, err := CatchEmit(bt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "A|B" {
+		t.Errorf("unexpected output '%s'", s)
+	}
+}
+
+func TestPool_limit(t *testing.T) {
+	const limit = 2
+	pool := NewPool(limit)
+	var running, max int32
+	gen := Generator(func(wr io.Writer) int {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if r <= m || atomic.CompareAndSwapInt32(&max, m, r) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return Print{V: "x"}.Emit(wr)
+	})
+	tmpl := NewTemplate("")
+	for i := 0; i < 6; i++ {
+		tmpl.Ph(fmt.Sprint("p", i))
+	}
+	bt := tmpl.NewBounT(nil)
+	for i := 0; i < 6; i++ {
+		bt.BindName(fmt.Sprint("p", i), pool.Async(gen))
+	}
+	var buf bytes.Buffer
+	bt.Emit(&buf)
+	if s := buf.String(); s != "xxxxxx" {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	if max > limit {
+		t.Errorf("%d futures running concurrently, limit is %d", max, limit)
+	}
+}
+
+func TestFuture_error(t *testing.T) {
+	tmpl := NewTemplate("").AddStr("<").Ph("a").AddStr(">")
+	bt := tmpl.NewBounT(nil)
+	failed := errors.New("failed")
+	bt.BindName("a", NewPool(0).Async(Generator(func(wr io.Writer) int {
+		panic(EmitError{Err: failed})
+	})))
+	var buf bytes.Buffer
+	if _, err := CatchEmit(bt, &buf); err != failed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFuture_nested(t *testing.T) {
+	pool := NewPool(1)
+	inner := NewTemplate("inner").AddStr("(").Ph("x").AddStr(")")
+	ibt := inner.NewBounT(nil)
+	ibt.BindName("x", pool.Async(Print{V: "inner"}))
+	outer := NewTemplate("outer").AddStr("[").Ph("x").AddStr("]")
+	obt := outer.NewBounT(nil)
+	obt.BindName("x", pool.Async(ibt))
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		obt.Emit(&buf)
+		done <- buf.String()
+	}()
+	select {
+	case s := <-done:
+		if s != "[(inner)]" {
+			t.Errorf("unexpected output '%s'", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested futures dead-locked")
+	}
+}
+
+func TestFuture_cancel(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	tmpl := NewTemplate("").Ph("a")
+	bt := tmpl.NewBounT(nil)
+	bt.BindName("a", NewPool(0).Async(Generator(func(wr io.Writer) int {
+		<-block
+		return 0
+	})))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := CatchEmitCtx(ctx, bt, io.Discard); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type prestartCount int
+
+func (pc *prestartCount) Emit(wr io.Writer) int { return 0 }
+
+func (pc *prestartCount) Prestart(ctx context.Context) { *pc++ }
+
+func TestBounT_prestartOnce(t *testing.T) {
+	var count prestartCount
+	var cnt Content = &count
+	for i := 0; i < 5; i++ {
+		bt := NewTemplate("").AddStr("(").Ph("x").AddStr(")").NewBounT(nil)
+		bt.BindName("x", cnt)
+		cnt = bt
+	}
+	nested := cnt
+	cached := NewCache(0, 0, 0).Get("k", func() Content { return nested })
+	bt := NewTemplate("").Ph("x").NewInitBounT(cached, nil)
+	var buf bytes.Buffer
+	bt.Emit(&buf)
+	if s := buf.String(); s != "((((()))))" {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	if count != 1 {
+		t.Errorf("prestarted %d times", count)
+	}
+}
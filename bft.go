@@ -69,26 +69,47 @@ func bftSplitSpec(specPh string) (fmt string, path string) {
 	return "", specPh
 }
 
+// BftContent creates the content that is bound to a BFT placeholder for
+// the value v. The format is the placeholder's format, if any.
+type BftContent func(format string, v interface{}) Content
+
+func bftDefault(format string, v interface{}) Content {
+	if len(format) == 0 {
+		return Print{v}
+	}
+	return fmtCnt{format, []interface{}{v}}
+}
+
 func (bt *BounT) Fill(data interface{}, overwrite bool) (missed int, err error) {
+	return bt.FillWith(data, overwrite, nil)
+}
+
+// FillWith is like Fill but uses mk to create the bound content. With mk
+// nil, values are printed with the fmt package.
+func (bt *BounT) FillWith(data interface{}, overwrite bool, mk BftContent) (missed int, err error) {
+	if mk == nil {
+		mk = bftDefault
+	}
 	tpl := bt.Template()
 	for ph, idxs := range tpl.plhNm2Idxs {
-		if !strings.HasPrefix(ph, BftMarker) {
+		if len(idxs) == 0 {
+			continue
+		}
+		d := tpl.PhDescAt(idxs[0])
+		if d.Kind != PhKindBFT || !strings.HasPrefix(ph, BftMarker) {
 			continue
 		}
-		ph := ph[1:]
 		// TODO maybe its efficient to 1st check if there is something to bind
 		//      consider overwrite
-		fmt, path := bftSplitSpec(ph)
+		_, path := bftSplitSpec(ph[len(BftMarker):])
 		bv, err := bftResolve(path, data) // TODO slow?
 		if err != nil {
 			return -1, err
 		}
 		if bv == nil {
 			missed++
-		} else if len(fmt) == 0 {
-			bt.BindP(idxs, bv)
 		} else {
-			bt.BindFmt(idxs, fmt, bv)
+			bt.Bind(idxs, mk(d.Format, bv))
 		}
 	}
 	return missed, nil
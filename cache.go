@@ -0,0 +1,225 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// EvictReason tells why an entry was removed from a Cache.
+type EvictReason int
+
+const (
+	// EvictExpired entries were older than the cache's TTL.
+	EvictExpired EvictReason = iota
+	// EvictCapacity entries were removed to stay within the cache limits.
+	EvictCapacity
+	// EvictInvalidated entries were removed by Invalidate or Clear.
+	EvictInvalidated
+)
+
+// CacheStats are the statistics of a Cache.
+type CacheStats struct {
+	Hits, Misses  int64
+	Evictions     int64
+	Invalidations int64
+	Entries       int
+	Bytes         int
+}
+
+// Cache memoizes the emitted output of content, typically a BounT that
+// changes rarely, by a user supplied key. Cached output is emitted as Data.
+// A Cache is safe for concurrent use.
+type Cache struct {
+	// TTL is the time an entry stays valid. Zero means forever.
+	TTL time.Duration
+	// MaxEntries limits the number of entries. Zero means no limit.
+	MaxEntries int
+	// MaxBytes limits the total size of cached output. Zero means no limit.
+	MaxBytes int
+	// OnEvict, if not nil, is called for each entry removed from the cache.
+	// It is called without holding the cache's lock.
+	OnEvict func(key string, reason EvictReason)
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List
+	stats   CacheStats
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	data    Data
+	expires time.Time
+}
+
+type cacheEviction struct {
+	key    string
+	reason EvictReason
+}
+
+func NewCache(ttl time.Duration, maxEntries, maxBytes int) *Cache {
+	return &Cache{TTL: ttl, MaxEntries: maxEntries, MaxBytes: maxBytes}
+}
+
+// Get returns the cached output for key as Data. Without a valid entry, Get
+// returns content that emits the content created by render and stores the
+// output in the cache. Render is called only once, at the latest when the
+// content is emitted. Output is not cached if emitting fails or if it alone
+// exceeds MaxBytes. Flush points in the rendered content have no effect.
+func (c *Cache) Get(key string, render func() Content) Content {
+	if d, ok := c.lookup(key); ok {
+		return d
+	}
+	return &cacheFill{c: c, key: key, render: render}
+}
+
+func (c *Cache) lookup(key string) (Data, bool) {
+	c.lock.Lock()
+	var evicted []cacheEviction
+	defer func() {
+		c.lock.Unlock()
+		c.notify(evicted)
+	}()
+	if elm, ok := c.entries[key]; ok {
+		e := elm.Value.(*cacheEntry)
+		if c.TTL <= 0 || c.clock().Before(e.expires) {
+			c.lru.MoveToFront(elm)
+			c.stats.Hits++
+			return e.data, true
+		}
+		c.remove(elm)
+		c.stats.Evictions++
+		evicted = append(evicted, cacheEviction{key, EvictExpired})
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+func (c *Cache) store(key string, data Data) {
+	if c.MaxBytes > 0 && len(data) > c.MaxBytes {
+		return
+	}
+	c.lock.Lock()
+	var evicted []cacheEviction
+	defer func() {
+		c.lock.Unlock()
+		c.notify(evicted)
+	}()
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+	if elm, ok := c.entries[key]; ok {
+		c.remove(elm)
+	}
+	e := &cacheEntry{key: key, data: data}
+	if c.TTL > 0 {
+		e.expires = c.clock().Add(c.TTL)
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	c.stats.Entries++
+	c.stats.Bytes += len(data)
+	for (c.MaxEntries > 0 && c.stats.Entries > c.MaxEntries) ||
+		(c.MaxBytes > 0 && c.stats.Bytes > c.MaxBytes) {
+		old := c.lru.Back().Value.(*cacheEntry)
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+		evicted = append(evicted, cacheEviction{old.key, EvictCapacity})
+	}
+}
+
+// Invalidate removes the entry for key from the cache and reports whether
+// there was such an entry.
+func (c *Cache) Invalidate(key string) bool {
+	c.lock.Lock()
+	elm, ok := c.entries[key]
+	if ok {
+		c.remove(elm)
+		c.stats.Invalidations++
+	}
+	c.lock.Unlock()
+	if ok {
+		c.notify([]cacheEviction{{key, EvictInvalidated}})
+	}
+	return ok
+}
+
+// Clear invalidates all entries of the cache.
+func (c *Cache) Clear() {
+	c.lock.Lock()
+	var evicted []cacheEviction
+	for elm := c.lru.Front(); elm != nil; elm = c.lru.Front() {
+		evicted = append(evicted, cacheEviction{
+			elm.Value.(*cacheEntry).key,
+			EvictInvalidated,
+		})
+		c.remove(elm)
+		c.stats.Invalidations++
+	}
+	c.lock.Unlock()
+	c.notify(evicted)
+}
+
+// Stats returns the current statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.stats
+}
+
+func (c *Cache) remove(elm *list.Element) {
+	e := c.lru.Remove(elm).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.stats.Entries--
+	c.stats.Bytes -= len(e.data)
+}
+
+func (c *Cache) notify(evicted []cacheEviction) {
+	if c.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.OnEvict(e.key, e.reason)
+	}
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+type cacheFill struct {
+	c      *Cache
+	key    string
+	render func() Content
+	once   sync.Once
+	cnt    Content
+}
+
+func (cf *cacheFill) content() Content {
+	cf.once.Do(func() { cf.cnt = cf.render() })
+	return cf.cnt
+}
+
+func (cf *cacheFill) Emit(wr io.Writer) int {
+	return cf.EmitCtx(context.Background(), wr)
+}
+
+func (cf *cacheFill) EmitCtx(ctx context.Context, wr io.Writer) int {
+	var buf bytes.Buffer
+	EmitCtx(ctx, cf.content(), &buf)
+	data := Data(buf.Bytes())
+	cf.c.store(cf.key, data)
+	return data.Emit(wr)
+}
+
+// Prestart renders the content, if not yet done, and prestarts it.
+func (cf *cacheFill) Prestart(ctx context.Context) { Prestart(ctx, cf.content()) }
@@ -0,0 +1,78 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCache_ttl(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(time.Minute, 0, 0)
+	c.now = func() time.Time { return now }
+	var evicted []string
+	c.OnEvict = func(key string, reason EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%s:%d", key, reason))
+	}
+	renders := 0
+	render := func() Content {
+		renders++
+		return Print{renders}
+	}
+	assertEqual(t, "1", emitString(t, NewTemplate("").Ph("x").NewInitBounT(c.Get("k", render), nil)))
+	assertEqual(t, "1", emitString(t, NewTemplate("").Ph("x").NewInitBounT(c.Get("k", render), nil)))
+	now = now.Add(2 * time.Minute)
+	assertEqual(t, "2", emitString(t, NewTemplate("").Ph("x").NewInitBounT(c.Get("k", render), nil)))
+	assertEqual(t, []string{"k:0"}, evicted)
+	assertEqual(t, CacheStats{Hits: 1, Misses: 2, Evictions: 1, Entries: 1, Bytes: 1}, c.Stats())
+}
+
+func TestCache_limits(t *testing.T) {
+	c := NewCache(0, 2, 5)
+	fill := func(key, value string) {
+		c.Get(key, func() Content { return Data(value) }).Emit(io.Discard)
+	}
+	fill("a", "12")
+	fill("b", "34")
+	fill("c", "56")
+	if _, ok := c.lookup("a"); ok {
+		t.Error("least recently used entry not evicted")
+	}
+	fill("d", "7890")
+	assertEqual(t, 1, c.Stats().Entries)
+	fill("e", "too long")
+	if _, ok := c.lookup("e"); ok {
+		t.Error("oversized entry was cached")
+	}
+	assertEqual(t, true, c.Invalidate("d"))
+	assertEqual(t, false, c.Invalidate("d"))
+	assertEqual(t, 0, c.Stats().Bytes)
+}
+
+func ExampleCache() {
+	menu := NewTemplate("menu").AddStr("<nav>").Ph("items").AddStr("</nav>\n")
+	cache := NewCache(10*time.Minute, 100, 0)
+	page := NewTemplate("page").Ph("menu").AddStr("content\n")
+	for i := 0; i < 2; i++ {
+		bt := page.NewBounT(nil)
+		bt.BindName("menu", cache.Get("menu", func() Content {
+			fmt.Println("render menu")
+			mbt := menu.NewBounT(nil)
+			mbt.BindPName("items", "home | about")
+			return mbt
+		}))
+		bt.Emit(os.Stdout)
+	}
+	fmt.Printf("%+v\n", cache.Stats())
+	// Output:
+	// render menu
+	// <nav>home | about</nav>
+	// content
+	// <nav>home | about</nav>
+	// content
+	// {Hits:1 Misses:1 Evictions:0 Invalidations:0 Entries:1 Bytes:24}
+}
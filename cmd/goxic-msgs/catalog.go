@@ -0,0 +1,103 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Messages is the content of a gotext catalog file, e.g.
+// messages.gotext.json. Only the fields used by goxic-msgs are modelled.
+type Messages struct {
+	Language string    `json:"language"`
+	Messages []Message `json:"messages"`
+}
+
+// Message is a single message of a gotext catalog file. Translation is
+// kept raw because gotext also allows select structures for plurals.
+type Message struct {
+	ID                string          `json:"id"`
+	Key               string          `json:"key,omitempty"`
+	Message           string          `json:"message"`
+	Translation       json.RawMessage `json:"translation"`
+	TranslatorComment string          `json:"translatorComment,omitempty"`
+	Placeholders      []Placeholder   `json:"placeholders,omitempty"`
+	Fuzzy             bool            `json:"fuzzy,omitempty"`
+	Position          string          `json:"position,omitempty"`
+}
+
+// Placeholder describes an argument of a message.
+type Placeholder struct {
+	ID     string `json:"id"`
+	String string `json:"string"`
+	ArgNum int    `json:"argNum"`
+	Expr   string `json:"expr,omitempty"`
+}
+
+var emptyTranslation = json.RawMessage(`""`)
+
+func readMessages(file string) (*Messages, error) {
+	rd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	res := new(Messages)
+	if err = json.NewDecoder(rd).Decode(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// readMessagesIfExists returns nil without error if file does not exist.
+func readMessagesIfExists(file string) (*Messages, error) {
+	res, err := readMessages(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return res, err
+}
+
+func (msgs *Messages) write(wr io.Writer) error {
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(msgs)
+}
+
+// add adds msg unless there already is a message with the same ID.
+func (msgs *Messages) add(msg Message) {
+	for _, m := range msgs.Messages {
+		if m.ID == msg.ID {
+			return
+		}
+	}
+	if msg.Translation == nil {
+		msg.Translation = emptyTranslation
+	}
+	msgs.Messages = append(msgs.Messages, msg)
+}
+
+// merge takes translations and translator comments from the messages in
+// old with the same ID.
+func (msgs *Messages) merge(old *Messages) {
+	if old == nil {
+		return
+	}
+	byID := make(map[string]*Message, len(old.Messages))
+	for i := range old.Messages {
+		byID[old.Messages[i].ID] = &old.Messages[i]
+	}
+	for i := range msgs.Messages {
+		m := &msgs.Messages[i]
+		if o := byID[m.ID]; o != nil {
+			m.Translation = o.Translation
+			m.TranslatorComment = o.TranslatorComment
+			m.Fuzzy = o.Fuzzy
+		}
+	}
+}
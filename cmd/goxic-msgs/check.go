@@ -0,0 +1,144 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+var phRegexp = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// check returns the problems of the translations in msgs. Each translation
+// must use the same placeholders and format verbs as its message. With
+// missing set, messages without translation are reported too.
+func check(msgs *Messages, missing bool) (problems []string) {
+	report := func(m *Message, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: message '%s': %s",
+			msgs.Language,
+			m.ID,
+			fmt.Sprintf(format, args...)))
+	}
+	for i := range msgs.Messages {
+		m := &msgs.Messages[i]
+		texts, err := translationTexts(m.Translation)
+		if err != nil {
+			report(m, "%s", err)
+			continue
+		}
+		if len(texts) == 0 {
+			if missing {
+				report(m, "missing translation")
+			}
+			continue
+		}
+		declared := make(map[string]bool)
+		for _, ph := range m.Placeholders {
+			declared[ph.ID] = true
+		}
+		literal := make(map[string]bool)
+		for _, ph := range phRegexp.FindAllStringSubmatch(m.Message, -1) {
+			literal[ph[1]] = !declared[ph[1]]
+		}
+		expect := usedPlaceholders(m.Message, declared)
+		expectVerbs := formatVerbs(m.Message)
+		for _, txt := range texts {
+			for _, ph := range phRegexp.FindAllStringSubmatch(txt, -1) {
+				if !declared[ph[1]] && !literal[ph[1]] {
+					report(m, "unknown placeholder '%s' in '%s'", ph[0], txt)
+				}
+			}
+			if got := usedPlaceholders(txt, declared); !reflect.DeepEqual(got, expect) {
+				report(m, "translation '%s' has placeholders %v, expected %v",
+					txt,
+					got,
+					expect)
+			}
+			if got := formatVerbs(txt); !reflect.DeepEqual(got, expectVerbs) {
+				report(m, "translation '%s' has format verbs %v, expected %v",
+					txt,
+					got,
+					expectVerbs)
+			}
+		}
+	}
+	return problems
+}
+
+// translationTexts returns the non-empty texts of a translation, which
+// is either a string or a gotext select structure.
+func translationTexts(raw json.RawMessage) (res []string, err error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var tr interface{}
+	if err = json.Unmarshal(raw, &tr); err != nil {
+		return nil, fmt.Errorf("invalid translation: %s", err)
+	}
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			if v != "" {
+				res = append(res, v)
+			}
+		case []interface{}:
+			for _, e := range v {
+				collect(e)
+			}
+		case map[string]interface{}:
+			for k, e := range v {
+				if k != "feature" && k != "arg" {
+					collect(e)
+				}
+			}
+		}
+	}
+	collect(tr)
+	sort.Strings(res)
+	return res, nil
+}
+
+// usedPlaceholders returns the sorted set of declared placeholders in txt.
+func usedPlaceholders(txt string, declared map[string]bool) (res []string) {
+	seen := make(map[string]bool)
+	for _, ph := range phRegexp.FindAllStringSubmatch(txt, -1) {
+		if declared[ph[1]] && !seen[ph[1]] {
+			seen[ph[1]] = true
+			res = append(res, ph[1])
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// formatVerbs returns the sorted fmt verbs in txt, not counting "%%".
+func formatVerbs(txt string) (res []string) {
+	for i := 0; i < len(txt); i++ {
+		if txt[i] != '%' {
+			continue
+		}
+		if i+1 < len(txt) && txt[i+1] == '%' {
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(txt) && !isVerb(txt[end]) {
+			end++
+		}
+		if end < len(txt) {
+			end++
+		}
+		res = append(res, txt[i:end])
+		i = end - 1
+	}
+	sort.Strings(res)
+	return res
+}
+
+func isVerb(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
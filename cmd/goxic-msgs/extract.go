@@ -0,0 +1,263 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"codeberg.org/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic/textmessage"
+)
+
+const textmessagePkg = "codeberg.org/fractalqb/goxic/textmessage"
+
+type extractor struct {
+	parser *goxic.Parser
+	marks  textmessage.Marks
+	exts   map[string]bool
+	msgs   Messages
+	warn   func(format string, args ...interface{})
+}
+
+// extractPath extracts messages from file or, if it is a directory, from
+// all Go and template files below it.
+func (x *extractor) extractPath(path string) error {
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		case strings.HasSuffix(file, "_test.go"):
+			return nil
+		case filepath.Ext(file) == ".go":
+			return x.extractGo(file)
+		case x.exts[filepath.Ext(file)]:
+			return x.extractTemplates(file)
+		}
+		return nil
+	})
+}
+
+func (x *extractor) extractTemplates(file string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	ts := make(map[string]*goxic.Template)
+	root := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if err = x.parser.Parse(bytes.NewReader(src), root, ts); err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	paths := make([]string, 0, len(ts))
+	for p := range ts {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		texts, err := textmessage.Extract(ts[p], x.marks)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+		for _, txt := range texts {
+			x.msgs.add(Message{
+				ID:       txt,
+				Message:  txt,
+				Position: textPosition(file, src, x.marks.Start+txt+x.marks.End),
+			})
+		}
+	}
+	return nil
+}
+
+// textPosition locates txt in the template source src. Whitespace
+// handling of the parser may prevent this; then only file is returned.
+func textPosition(file string, src []byte, txt string) string {
+	idx := bytes.Index(src, []byte(txt))
+	if idx < 0 {
+		return file
+	}
+	line := 1 + bytes.Count(src[:idx], []byte{'\n'})
+	col := 1 + idx - (bytes.LastIndexByte(src[:idx], '\n') + 1)
+	return fmt.Sprintf("%s:%d:%d", file, line, col)
+}
+
+// extractGo extracts the format strings of textmessage.Msg calls from a
+// Go source file. Only string literals are recognised as format.
+func (x *extractor) extractGo(file string) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return err
+	}
+	pkgName := ""
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == textmessagePkg {
+			if imp.Name != nil {
+				pkgName = imp.Name.Name
+			} else {
+				pkgName = "textmessage"
+			}
+		}
+	}
+	if pkgName == "" || pkgName == "_" {
+		return nil
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Msg" {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != pkgName {
+			return true
+		}
+		lit, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			x.warn("%s: non-literal message format", fset.Position(call.Args[1].Pos()))
+			return true
+		}
+		format, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			x.warn("%s: %s", fset.Position(lit.Pos()), err)
+			return true
+		}
+		txt, phs := formatPlaceholders(format, call.Args[2:])
+		x.msgs.add(Message{
+			ID:           txt,
+			Key:          format,
+			Message:      txt,
+			Placeholders: phs,
+			Position:     fset.Position(lit.Pos()).String(),
+		})
+		return true
+	})
+	return nil
+}
+
+// formatPlaceholders replaces the verbs of the fmt format with gotext
+// placeholders "{ID}". Placeholder IDs are derived from the argument
+// expressions args if possible.
+func formatPlaceholders(format string, args []ast.Expr) (string, []Placeholder) {
+	var (
+		txt    strings.Builder
+		phs    []Placeholder
+		argNum = 0
+	)
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i+1 == len(format) {
+			txt.WriteByte(c)
+			continue
+		}
+		if format[i+1] == '%' {
+			txt.WriteString("%%")
+			i++
+			continue
+		}
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		spec := format[start+1 : i]
+		simple := true
+	scan:
+		for i < len(format) {
+			c = format[i]
+			switch {
+			case c == '[':
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					i = len(format)
+					continue
+				}
+				if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil && n > 0 {
+					argNum = n - 1
+				}
+				i += end + 1
+				continue
+			case c == '*':
+				argNum++
+				simple = false
+			case c == '.' || ('0' <= c && c <= '9'):
+				spec += string(c)
+			default:
+				break scan
+			}
+			i++
+		}
+		if i == len(format) {
+			txt.WriteString(format[start:])
+			break
+		}
+		argNum++
+		verb := format[i]
+		if simple {
+			spec = fmt.Sprintf("%%%s[%d]%c", spec, argNum, verb)
+		} else {
+			spec = format[start : i+1]
+		}
+		ph := placeholderFor(phs, argNum)
+		if ph == nil {
+			phs = append(phs, Placeholder{
+				ID:     placeholderID(phs, argNum, args),
+				String: spec,
+				ArgNum: argNum,
+			})
+			ph = &phs[len(phs)-1]
+			if argNum <= len(args) {
+				ph.Expr = types.ExprString(args[argNum-1])
+			}
+		}
+		txt.WriteString("{" + ph.ID + "}")
+	}
+	return txt.String(), phs
+}
+
+func placeholderFor(phs []Placeholder, argNum int) *Placeholder {
+	for i := range phs {
+		if phs[i].ArgNum == argNum {
+			return &phs[i]
+		}
+	}
+	return nil
+}
+
+func placeholderID(phs []Placeholder, argNum int, args []ast.Expr) string {
+	id := ""
+	if argNum <= len(args) {
+		switch arg := args[argNum-1].(type) {
+		case *ast.Ident:
+			id = arg.Name
+		case *ast.SelectorExpr:
+			id = arg.Sel.Name
+		}
+	}
+	if id == "" {
+		return fmt.Sprintf("Arg_%d", argNum)
+	}
+	rs := []rune(id)
+	rs[0] = unicode.ToUpper(rs[0])
+	id = string(rs)
+	for _, ph := range phs {
+		if ph.ID == id {
+			return fmt.Sprintf("Arg_%d", argNum)
+		}
+	}
+	return id
+}
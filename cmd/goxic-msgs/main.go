@@ -0,0 +1,150 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+
+// Command goxic-msgs extracts translatable messages for gotext catalogs and
+// checks translations.
+//
+// Usage:
+//
+//	goxic-msgs extract [flags] path...
+//	goxic-msgs check [-missing] file...
+//
+// The extract command scans template files and Go source files below the
+// given paths. From templates it extracts the translatable text delimited
+// by marks, see textmessage.Marks. From Go files it extracts the format
+// strings of textmessage.Msg calls. The messages are written in the
+// format of gotext catalog files, e.g. messages.gotext.json. If the output
+// file already exists its translations are kept.
+//
+// Templates are parsed without wrappers and attributes, i.e. application
+// specific wrappers need not be registered. The whitespace mode must be
+// the same as with the application's parser to get the same messages.
+//
+// The check command verifies that the translations in gotext catalog files
+// use the same placeholders and format verbs as their messages.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic/textmessage"
+)
+
+var wsModes = map[string]goxic.WSMode{
+	"preserve": goxic.WSPreserve,
+	"trim":     goxic.WSTrimLines,
+	"collapse": goxic.WSCollapse,
+	"html":     goxic.WSMinifyHTML,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goxic-msgs extract [flags] path...")
+	fmt.Fprintln(os.Stderr, "       goxic-msgs check [-missing] file...")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "extract":
+		err = extractCmd(os.Args[2:])
+	case "check":
+		err = checkCmd(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "goxic-msgs:", err)
+		os.Exit(1)
+	}
+}
+
+func extractCmd(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	out := flags.String("out", "-", "output `file`, '-' for stdout")
+	lang := flags.String("lang", "en", "`language` of the catalog")
+	exts := flags.String("ext", ".html", "comma separated template file `extensions`")
+	markStart := flags.String("mark-start", textmessage.DefaultMarks.Start,
+		"start mark of translatable text")
+	markEnd := flags.String("mark-end", textmessage.DefaultMarks.End,
+		"end mark of translatable text")
+	phStart := flags.String("ph-start", "`", "start of inline placeholders")
+	phEnd := flags.String("ph-end", "`", "end of inline placeholders")
+	comStart := flags.String("com-start", "<!--", "start of line comments")
+	comEnd := flags.String("com-end", "-->", "end of line comments")
+	ws := flags.String("ws", "preserve",
+		"whitespace `mode` of the parser: preserve, trim, collapse or html")
+	flags.Parse(args)
+	x := extractor{
+		parser: goxic.NewParser(*phStart, *phEnd, *comStart, *comEnd),
+		marks:  textmessage.Marks{Start: *markStart, End: *markEnd},
+		exts:   make(map[string]bool),
+		msgs:   Messages{Language: *lang},
+		warn: func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, "goxic-msgs: "+format+"\n", args...)
+		},
+	}
+	mode, ok := wsModes[*ws]
+	if !ok {
+		return fmt.Errorf("unknown whitespace mode '%s'", *ws)
+	}
+	x.parser.WS = mode
+	for _, ext := range strings.Split(*exts, ",") {
+		x.exts[strings.TrimSpace(ext)] = true
+	}
+	for _, path := range flags.Args() {
+		if err := x.extractPath(path); err != nil {
+			return err
+		}
+	}
+	if *out == "-" {
+		return x.msgs.write(os.Stdout)
+	}
+	old, err := readMessagesIfExists(*out)
+	if err != nil {
+		return err
+	}
+	x.msgs.merge(old)
+	wr, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err = x.msgs.write(wr); err != nil {
+		wr.Close()
+		return err
+	}
+	return wr.Close()
+}
+
+func checkCmd(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	missing := flags.Bool("missing", false, "report messages without translation")
+	flags.Parse(args)
+	count := 0
+	for _, file := range flags.Args() {
+		msgs, err := readMessages(file)
+		if err != nil {
+			return err
+		}
+		count += report(os.Stdout, file, check(msgs, *missing))
+	}
+	if count > 0 {
+		return fmt.Errorf("%d problems in translations", count)
+	}
+	return nil
+}
+
+func report(wr io.Writer, file string, problems []string) int {
+	for _, p := range problems {
+		fmt.Fprintf(wr, "%s: %s\n", file, p)
+	}
+	return len(problems)
+}
@@ -0,0 +1,134 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic/textmessage"
+)
+
+func TestFormatPlaceholders(t *testing.T) {
+	args := []ast.Expr{
+		ast.NewIdent("count"),
+		&ast.SelectorExpr{X: ast.NewIdent("user"), Sel: ast.NewIdent("Name")},
+		&ast.BasicLit{Value: "4711"},
+	}
+	txt, phs := formatPlaceholders("%d files of %s (%05.1[3]f%%), %[1]d", args)
+	if txt != "{Count} files of {Name} ({Arg_3}%%), {Count}" {
+		t.Errorf("wrong message text '%s'", txt)
+	}
+	expect := []Placeholder{
+		{ID: "Count", String: "%[1]d", ArgNum: 1, Expr: "count"},
+		{ID: "Name", String: "%[2]s", ArgNum: 2, Expr: "user.Name"},
+		{ID: "Arg_3", String: "%05.1[3]f", ArgNum: 3, Expr: "4711"},
+	}
+	if !reflect.DeepEqual(phs, expect) {
+		t.Errorf("wrong placeholders %+v", phs)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "page.html"), "<h1>[[Welcome]]</h1>\n"+
+		"<!-- >>> sub >>> -->\n"+
+		"<p>[[Hello]] `name|html`</p>\n"+
+		"<!-- <<< sub <<< -->\n")
+	writeFile(t, filepath.Join(dir, "code.go"), `package code
+
+import tm "codeberg.org/fractalqb/goxic/textmessage"
+
+func msg(pr *message.Printer, n int) tm.Content {
+	return tm.Msg(pr, "%d new mails", n)
+}
+`)
+	x := extractor{
+		parser: goxic.NewParser("`", "`", "<!--", "-->"),
+		marks:  textmessage.DefaultMarks,
+		exts:   map[string]bool{".html": true},
+		warn:   t.Errorf,
+	}
+	if err := x.extractPath(dir); err != nil {
+		t.Fatal(err)
+	}
+	var ids, poss []string
+	for _, m := range x.msgs.Messages {
+		ids = append(ids, m.ID)
+		poss = append(poss, m.Position)
+	}
+	if !reflect.DeepEqual(ids, []string{"{N} new mails", "Welcome", "Hello"}) {
+		t.Errorf("wrong messages %v", ids)
+	}
+	if !reflect.DeepEqual(poss, []string{
+		filepath.Join(dir, "code.go") + ":6:20",
+		filepath.Join(dir, "page.html") + ":1:5",
+		filepath.Join(dir, "page.html") + ":3:4",
+	}) {
+		t.Errorf("wrong positions %v", poss)
+	}
+	if key := x.msgs.Messages[0].Key; key != "%d new mails" {
+		t.Errorf("wrong key '%s'", key)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var msgs Messages
+	err := json.Unmarshal([]byte(`{
+	"language": "de",
+	"messages": [
+		{"id": "ok", "message": "{N} of {M}", "translation": "{M} von {N}",
+		 "placeholders": [{"id": "N"}, {"id": "M"}]},
+		{"id": "lost", "message": "{N} mails", "translation": "Mails",
+		 "placeholders": [{"id": "N"}]},
+		{"id": "unknown", "message": "{N} mails", "translation": "{N} {Mails}",
+		 "placeholders": [{"id": "N"}]},
+		{"id": "verb", "message": "100%% sure", "translation": "100% sicher"},
+		{"id": "missing", "message": "Hello", "translation": ""},
+		{"id": "plural", "message": "{N} mails", "placeholders": [{"id": "N"}],
+		 "translation": {"select": {"feature": "plural", "arg": "N", "cases": {
+			"one": {"msg": "eine Mail"}, "other": {"msg": "{N} Mails"}}}}}
+	]}`), &msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probs := check(&msgs, false)
+	if len(probs) != 4 {
+		t.Errorf("expected 4 problems, got %d: %v", len(probs), probs)
+	}
+	if probs := check(&msgs, true); len(probs) != 5 {
+		t.Errorf("expected 5 problems, got %d: %v", len(probs), probs)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	msgs := Messages{Language: "de"}
+	msgs.add(Message{ID: "a", Message: "a"})
+	msgs.add(Message{ID: "b", Message: "b"})
+	msgs.add(Message{ID: "a", Message: "a"})
+	msgs.merge(&Messages{Messages: []Message{
+		{ID: "b", Translation: json.RawMessage(`"B"`)},
+		{ID: "c", Translation: json.RawMessage(`"C"`)},
+	}})
+	if len(msgs.Messages) != 2 {
+		t.Fatalf("wrong number of messages %d", len(msgs.Messages))
+	}
+	if tr := string(msgs.Messages[0].Translation); tr != `""` {
+		t.Errorf("wrong translation of a: %s", tr)
+	}
+	if tr := string(msgs.Messages[1].Translation); tr != `"B"` {
+		t.Errorf("wrong translation of b: %s", tr)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"context"
+	"io"
+)
+
+// ContentCtx is content that can observe a context.Context during
+// emission, e.g. to stop long running work when a request is canceled or
+// to read request-scoped values. Content that wraps other content should
+// implement ContentCtx to pass the context on, see EmitCtx.
+type ContentCtx interface {
+	Content
+	EmitCtx(ctx context.Context, wr io.Writer) int
+}
+
+// EmitCtx emits c with ctx if c is a ContentCtx. Otherwise c is emitted
+// with its Emit method.
+func EmitCtx(ctx context.Context, c Content, wr io.Writer) int {
+	if cc, ok := c.(ContentCtx); ok {
+		return cc.EmitCtx(ctx, wr)
+	}
+	return c.Emit(wr)
+}
+
+// CatchEmitCtx is like CatchEmit but emits bt with ctx. When ctx is done
+// before bt is completely emitted the returned error wraps ctx.Err(), i.e.
+// errors.Is(err, context.Canceled) reports a canceled context.
+func CatchEmitCtx(ctx context.Context, bt *BounT, wr io.Writer) (n int, err error) {
+	return catchEmit(func() int { return bt.EmitCtx(ctx, wr) })
+}
+
+// GeneratorCtx is a Generator that gets the context from EmitCtx. When
+// emitted with Emit it gets context.Background().
+type GeneratorCtx func(ctx context.Context, wr io.Writer) int
+
+func (f GeneratorCtx) Emit(wr io.Writer) int {
+	return f(context.Background(), wr)
+}
+
+func (f GeneratorCtx) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return f(ctx, wr)
+}
+
+func (bt *BounT) BindGenCtx(phIdxs []int,
+	f func(ctx context.Context, wr io.Writer) int) int {
+	return bt.Bind(phIdxs, GeneratorCtx(f))
+}
+
+func (bt *BounT) BindGenCtxName(name string,
+	f func(ctx context.Context, wr io.Writer) int) error {
+	return bt.BindName(name, GeneratorCtx(f))
+}
+
+func (bt *BounT) BindGenCtxIfName(name string,
+	f func(ctx context.Context, wr io.Writer) int) {
+	bt.BindIfName(name, GeneratorCtx(f))
+}
@@ -0,0 +1,92 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func ExampleBounT_EmitCtx() {
+	tmpl := NewTemplate("hello").AddStr("Hello ").Ph("user").AddStr("!\n")
+	bt := tmpl.NewBounT(nil)
+	bt.BindGenCtxName("user", func(ctx context.Context, wr io.Writer) int {
+		return Print{V: ctx.Value(ctxKey{})}.Emit(wr)
+	})
+	ctx := context.WithValue(context.Background(), ctxKey{}, "John")
+	bt.EmitCtx(ctx, os.Stdout)
+	// Output:
+	// Hello John!
+}
+
+func TestEmitCtx_nested(t *testing.T) {
+	inner := NewTemplate("inner").AddStr("[").Ph("val").AddStr("]")
+	ibt := inner.NewBounT(nil)
+	ibt.BindGenCtxName("val", func(ctx context.Context, wr io.Writer) int {
+		return Print{V: ctx.Value(ctxKey{})}.Emit(wr)
+	})
+	outer := NewTemplate("outer").Ph("a").AddStr("-").Ph("b")
+	obt := outer.NewBounT(nil)
+	obt.BindName("a", ibt)
+	obt.BindName("b", &Embracer{Prefix: []byte("<"), Cnt: ibt, Postfix: []byte(">")})
+	var buf bytes.Buffer
+	ctx := context.WithValue(context.Background(), ctxKey{}, 4711)
+	if _, err := CatchEmitCtx(ctx, obt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "[4711]-<[4711]>" {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	buf.Reset()
+	obt.Emit(&buf)
+	if s := buf.String(); s != "[<nil>]-<[<nil>]>" {
+		t.Errorf("unexpected output without context '%s'", s)
+	}
+}
+
+func TestEmitCtx_cancel(t *testing.T) {
+	tmpl := NewTemplate("list").Ph("a").AddStr(",").Ph("b").AddStr(",").Ph("c")
+	bt := tmpl.NewBounT(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bt.BindPName("a", "A")
+	bt.BindGenCtxName("b", func(ctx context.Context, wr io.Writer) int {
+		cancel()
+		return Print{V: "B"}.Emit(wr)
+	})
+	bt.BindPName("c", "C")
+	var buf bytes.Buffer
+	n, err := CatchEmitCtx(ctx, bt, &buf)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := buf.String(); s != "A,B," || n != 4 {
+		t.Errorf("unexpected output '%s' (%d bytes)", s, n)
+	}
+}
+
+func TestEmitError_Unwrap(t *testing.T) {
+	err := fmt.Errorf("render: %w", EmitError{Err: context.DeadlineExceeded})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("EmitError does not unwrap")
+	}
+}
+
+func TestCatchEmit_repanic(t *testing.T) {
+	tmpl := NewTemplate("boom").Ph("x")
+	bt := tmpl.NewBounT(nil)
+	bt.BindGenName("x", func(wr io.Writer) int { panic("boom") })
+	defer func() {
+		if rek := recover(); rek != "boom" {
+			t.Errorf("unexpected panic value %v", rek)
+		}
+	}()
+	CatchEmit(bt, io.Discard)
+}
@@ -0,0 +1,8 @@
+module codeberg.org/fractalqb/goxic
+
+go 1.21
+
+require (
+	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
+)
@@ -3,6 +3,7 @@
 package goxic
 
 import (
+	"context"
 	"fmt"
 	"io"
 )
@@ -98,6 +99,10 @@ type Embracer struct {
 }
 
 func (e *Embracer) Emit(wr io.Writer) (res int) {
+	return e.EmitCtx(context.Background(), wr)
+}
+
+func (e *Embracer) EmitCtx(ctx context.Context, wr io.Writer) (res int) {
 	if e.Prefix != nil {
 		n, err := wr.Write(e.Prefix)
 		if err != nil {
@@ -105,7 +110,7 @@ func (e *Embracer) Emit(wr io.Writer) (res int) {
 		}
 		res = n
 	}
-	res += e.Cnt.Emit(wr)
+	res += EmitCtx(ctx, e.Cnt, wr)
 	if e.Postfix != nil {
 		n, err := wr.Write(e.Postfix)
 		if err != nil {
@@ -116,6 +121,8 @@ func (e *Embracer) Emit(wr io.Writer) (res int) {
 	return res
 }
 
+func (e *Embracer) Prestart(ctx context.Context) { Prestart(ctx, e.Cnt) }
+
 func Embrace(prefix string, c Content, postfix string) Embracer {
 	return Embracer{
 		Prefix:  []byte(prefix),
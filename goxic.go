@@ -4,6 +4,7 @@ package goxic
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"regexp"
@@ -26,10 +27,16 @@ type PhIdx = int
 // template (BounT) to hold the bindings. When all placeholders ar
 // bound the resulting content can be emitted.
 type Template struct {
-	Name       string
+	Name string
+	// MediaType is the media type of the template's output, e.g.
+	// "text/html", if known.
+	MediaType  string
 	fix        []fragment
 	plhAt      []string
-	escAt      []CntWrapper // TODO
+	escAt      []CntWrapper
+	wrapNm     [][]string
+	descAt     []*PhDesc
+	flushAt    []bool
 	plhNm2Idxs map[string][]int
 }
 
@@ -44,7 +51,7 @@ func NewTemplate(name string) *Template {
 // Note that static context is merged to preceeding static content as
 // long as no placholder was added before.
 func (t *Template) AddFix(fixFragment []byte) *Template {
-	if phnm := t.PhAt(len(t.fix)); len(phnm) > 0 {
+	if phnm := t.PhAt(len(t.fix)); len(phnm) > 0 || t.FlushAt(len(t.fix)) {
 		t.fix = append(t.fix, fixFragment)
 	} else if len(fixFragment) > 0 {
 		if len(t.fix) == 0 {
@@ -68,7 +75,7 @@ func (t *Template) AddStr(str string) *Template {
 // Placeholder adds a new placeholder to the end of the template.
 func (t *Template) Ph(name string) *Template {
 	idx := t.FixCount()
-	if phnm := t.PhAt(idx); len(phnm) > 0 {
+	if phnm := t.PhAt(idx); len(phnm) > 0 || t.FlushAt(idx) {
 		t.AddFix([]byte{})
 		idx++
 	}
@@ -85,6 +92,27 @@ func (t *Template) Ph(name string) *Template {
 	return t
 }
 
+// Flush adds a flush point to the end of the template. When a BounT is
+// emitted, the writer is flushed at each flush point if it has a method
+// Flush() or Flush() error, e.g. http.Flusher or bufio.Writer. The escaping
+// writers of package html pass flushes on. Flush points have no effect in
+// content that is emitted into a buffer, e.g. by Future, Cache or
+// XformWrapper.
+func (t *Template) Flush() *Template {
+	idx := len(t.fix)
+	for len(t.flushAt) <= idx {
+		t.flushAt = append(t.flushAt, false)
+	}
+	t.flushAt[idx] = true
+	return t
+}
+
+// FlushAt reports whether the template has a flush point after the
+// placeholder position idx, i.e. before the static content idx.
+func (t *Template) FlushAt(idx int) bool {
+	return idx >= 0 && idx < len(t.flushAt) && t.flushAt[idx]
+}
+
 func (t *Template) PhWrap(name string, wrapper CntWrapper) *Template {
 	res := t.Ph(name)
 	t.Wrap(wrapper, len(t.plhAt)-1)
@@ -98,8 +126,12 @@ func (t *Template) Wrap(wrapper CntWrapper, idxs ...int) {
 			copy(nesc, t.escAt)
 			nesc[idx] = wrapper
 			t.escAt = nesc
+			nnm := make([][]string, idx+1)
+			copy(nnm, t.wrapNm)
+			t.wrapNm = nnm
 		} else {
 			t.escAt[idx] = wrapper
+			t.wrapNm[idx] = nil
 		}
 	}
 }
@@ -284,6 +316,20 @@ func (t *Template) XformPhs(merge bool, x func(string) string) error {
 	return t.RenamePhs(merge, cur, nnm)
 }
 
+// XformFixs replaces each fixed fragment of t with the result of x. The
+// first error returned by x is returned and leaves t partially
+// transformed.
+func (t *Template) XformFixs(x func(frag []byte) ([]byte, error)) error {
+	for i, frag := range t.fix {
+		nf, err := x(frag)
+		if err != nil {
+			return err
+		}
+		t.fix[i] = nf
+	}
+	return nil
+}
+
 func (t *Template) Static() ([]byte, bool) {
 	if t.PhNum() == 0 {
 		switch t.FixCount() {
@@ -292,6 +338,13 @@ func (t *Template) Static() ([]byte, bool) {
 		case 1:
 			return t.FixAt(0), true
 		default:
+			if len(t.flushAt) > 0 {
+				var res []byte
+				for _, f := range t.fix {
+					res = append(res, f...)
+				}
+				return res, true
+			}
 			panic("template " + t.Name + " without placeholder has many fix fragments")
 		}
 	} else {
@@ -351,10 +404,11 @@ func (t *Template) NewInitBounT(cnt Content, reuse *BounT) *BounT {
 	reuse = t.NewBounT(reuse)
 	for i := 0; i < len(reuse.fill); i++ {
 		if len(t.PhAt(i)) > 0 {
+			c := cnt
 			if esc := t.WrapAt(i); esc != nil {
-				cnt = esc(cnt)
+				c = esc(cnt)
 			}
-			reuse.fill[i] = cnt
+			reuse.fill[i] = c
 		}
 	}
 	return reuse
@@ -372,10 +426,11 @@ func (bt *BounT) Bind(phIdxs []int, cnt Content) (anonymous int) {
 		if len(t.PhAt(i)) == 0 {
 			anonymous++
 		}
+		c := cnt
 		if esc := t.WrapAt(i); esc != nil {
-			cnt = esc(cnt)
+			c = esc(cnt)
 		}
-		bt.fill[i] = cnt
+		bt.fill[i] = c
 	}
 	return anonymous
 }
@@ -411,17 +466,21 @@ type EmitError struct {
 }
 
 func CatchEmit(bt *BounT, wr io.Writer) (n int, err error) {
+	return catchEmit(func() int { return bt.Emit(wr) })
+}
+
+func catchEmit(emit func() int) (n int, err error) {
 	defer func() {
 		if rek := recover(); rek != nil {
 			if ee, ok := rek.(EmitError); ok {
 				n = ee.Count
 				err = ee.Err
 			} else {
-				panic(err)
+				panic(rek)
 			}
 		}
 	}()
-	n = bt.Emit(wr)
+	n = emit()
 	return n, nil
 }
 
@@ -429,21 +488,29 @@ func (ee EmitError) Error() string {
 	return ee.Err.Error()
 }
 
+func (ee EmitError) Unwrap() error { return ee.Err }
+
 // Method Emit panics with an EmitError when an error occures during
 // emitting. Use CatchEmit() to easily get back to an io.Writer like
 // error return.
 func (bt *BounT) Emit(out io.Writer) (n int) {
-	n = 0
+	return bt.EmitCtx(context.Background(), out)
+}
+
+// EmitCtx is like Emit but passes ctx to bound ContentCtx, e.g. nested
+// BounTs or GeneratorCtx. When ctx is done before a placeholder is emitted,
+// EmitCtx panics with an EmitError that wraps ctx.Err().
+func (bt *BounT) EmitCtx(ctx context.Context, out io.Writer) (n int) {
+	if ctx.Value(prestartedKey{}) == nil {
+		bt.Prestart(ctx)
+		ctx = context.WithValue(ctx, prestartedKey{}, true)
+	}
 	fixs := bt.tmpl.fix
 	fCount := len(fixs)
 	for i := 0; i < fCount; i++ {
-		if f := bt.fill[i]; f != nil {
-			n += f.Emit(out)
-		} else if ph := bt.tmpl.PhAt(i); len(ph) > 0 {
-			panic(EmitError{n,
-				fmt.Errorf("unbound placeholder '%s' in template '%s'",
-					ph,
-					bt.tmpl.Name)})
+		n += bt.emitFill(ctx, out, i, n)
+		if bt.tmpl.FlushAt(i) {
+			flush(out, n)
 		}
 		if c, err := out.Write(fixs[i]); err != nil {
 			panic(EmitError{n + c, err})
@@ -451,60 +518,160 @@ func (bt *BounT) Emit(out io.Writer) (n int) {
 			n += c
 		}
 	}
-	if f := bt.fill[fCount]; f != nil {
-		n += f.Emit(out)
-	} else if ph := bt.tmpl.PhAt(fCount); len(ph) > 0 {
-		panic(EmitError{n,
-			fmt.Errorf("unbound placeholder '%s' in template '%s'",
-				ph,
-				bt.tmpl.Name)})
+	n += bt.emitFill(ctx, out, fCount, n)
+	if bt.tmpl.FlushAt(fCount) {
+		flush(out, n)
 	}
 	return n
 }
 
+func flush(out io.Writer, n int) {
+	if err := Flush(out); err != nil {
+		panic(EmitError{n, err})
+	}
+}
+
+// Flush flushes w if it has a method Flush() or Flush() error. Writers that
+// wrap another writer can use Flush to pass flushes on.
+func Flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+func (bt *BounT) emitFill(ctx context.Context, out io.Writer, idx int, n int) int {
+	f := bt.fill[idx]
+	if f == nil {
+		if len(bt.tmpl.PhAt(idx)) == 0 {
+			return 0
+		}
+		return bt.emitUnbound(out, idx, n)
+	}
+	if err := ctx.Err(); err != nil {
+		panic(EmitError{n, err})
+	}
+	return EmitCtx(ctx, f, out)
+}
+
+// emitUnbound emits the default of an unbound placeholder. It panics if the
+// placeholder is neither optional nor has a default.
+func (bt *BounT) emitUnbound(out io.Writer, idx int, n int) int {
+	if idx < len(bt.tmpl.descAt) && bt.tmpl.descAt[idx] != nil {
+		d := bt.tmpl.descAt[idx]
+		if len(d.Default) > 0 {
+			var cnt Content = Data(d.Default)
+			if esc := bt.tmpl.WrapAt(idx); esc != nil {
+				cnt = esc(cnt)
+			}
+			return cnt.Emit(out)
+		} else if d.Optional {
+			return 0
+		}
+	}
+	panic(EmitError{n,
+		fmt.Errorf("unbound placeholder '%s' in template '%s'",
+			bt.tmpl.PhAt(idx),
+			bt.tmpl.Name)})
+}
+
 const NameSep = ':'
 
+// FixNaming computes the name of placeholder ph of the sub-template sub
+// when ph is lifted into the template created by BounT.FixateWith.
+type FixNaming func(sub *Template, ph string) string
+
+// FixPrefixSub prefixes lifted placeholders with the sub-template's name and
+// NameSep. This is the naming used by BounT.Fixate.
+func FixPrefixSub(sub *Template, ph string) string {
+	return sub.Name + string(NameSep) + ph
+}
+
+// FixKeepName keeps the names of lifted placeholders. Lifted placeholders
+// with the same name get merged.
+func FixKeepName(sub *Template, ph string) string { return ph }
+
+// Fixate creates a new template from the bound template where all bound
+// content is folded into the fixed fragments. Unbound placeholders keep their
+// wrappers and descriptors. Placeholders of bound sub-templates are lifted
+// into the new template and get named with FixPrefixSub. If all placeholders
+// are bound the result is a static template.
 func (bt *BounT) Fixate() *Template {
-	it := bt.Template()
-	if it.PhNum() == 0 {
-		return nil
+	return bt.FixateWith(nil)
+}
+
+// FixateWith works like Fixate with the naming of lifted placeholders
+// computed by naming. If naming is nil FixPrefixSub is used. If fold is not
+// empty, only the content bound to the placeholders named in fold is folded.
+// All other placeholders of bt's template remain placeholders in the new
+// template.
+func (bt *BounT) FixateWith(naming FixNaming, fold ...string) *Template {
+	if naming == nil {
+		naming = FixPrefixSub
+	}
+	var foldPhs map[string]bool
+	if len(fold) > 0 {
+		foldPhs = make(map[string]bool)
+		for _, ph := range fold {
+			foldPhs[ph] = true
+		}
 	}
-	res := NewTemplate(it.Name)
-	bt.fix(res, "")
+	res := NewTemplate(bt.Template().Name)
+	res.MediaType = bt.Template().MediaType
+	bt.fix(res, func(ph string) string { return ph }, naming, foldPhs)
 	return res
 }
 
-func (bt *BounT) fix(to *Template, phPrefix string) {
+func (bt *BounT) fix(
+	to *Template,
+	rename func(string) string,
+	naming FixNaming,
+	fold map[string]bool,
+) {
 	it := bt.Template()
-	for idx, frag := range it.fix {
+	for idx := 0; idx <= len(it.fix); idx++ {
 		pre := bt.fill[idx]
+		phnm := it.PhAt(idx)
+		if fold != nil && len(phnm) > 0 && !fold[phnm] {
+			pre = nil
+		}
 		if pre == nil {
-			if phnm := it.PhAt(idx); len(phnm) > 0 {
-				to.Ph(phPrefix + phnm)
+			if len(phnm) > 0 {
+				to.phFrom(it, idx, rename(phnm))
 			}
 		} else if sbt, ok := pre.(*BounT); ok {
-			subPrefix := phPrefix + sbt.Template().Name + string(NameSep)
-			sbt.fix(to, subPrefix)
+			sub := sbt.Template()
+			sbt.fix(to, func(ph string) string {
+				return rename(naming(sub, ph))
+			}, naming, nil)
 		} else {
 			buf := bytes.NewBuffer(nil)
 			pre.Emit(buf)
 			to.AddStr(buf.String())
 		}
-		to.AddFix(frag)
-	}
-	idx := len(it.fix)
-	pre := bt.fill[idx]
-	if pre == nil {
-		if phnm := it.PhAt(idx); len(phnm) > 0 {
-			to.Ph(phPrefix + phnm)
+		if it.FlushAt(idx) {
+			to.Flush()
 		}
-	} else if sbt, ok := pre.(*BounT); ok {
-		subPrefix := phPrefix + sbt.Template().Name + string(NameSep)
-		sbt.fix(to, subPrefix)
-	} else {
-		buf := bytes.NewBuffer(nil)
-		pre.Emit(buf)
-		to.AddStr(buf.String())
+		if idx < len(it.fix) {
+			to.AddFix(it.fix[idx])
+		}
+	}
+}
+
+// phFrom adds placeholder name to the end of t with the wrappers and the
+// descriptor of the placeholder at position idx in template src.
+func (t *Template) phFrom(src *Template, idx int, name string) {
+	t.Ph(name)
+	tidx := len(t.plhAt) - 1
+	if w := src.WrapAt(idx); w != nil {
+		t.Wrap(w, tidx)
+		t.wrapNm[tidx] = append([]string(nil), src.WrapNamesAt(idx)...)
+	}
+	if idx < len(src.descAt) && src.descAt[idx] != nil {
+		t.setDescAt(tidx, src.descAt[idx])
 	}
 }
 
@@ -529,7 +696,9 @@ const (
 
 func parseTag(tag string) (mode tagMode, placeholder string, err error) {
 	mode = tagMand
-	if len(tag) > 0 {
+	if tag == "-" {
+		return tagIgnore, tag, nil
+	} else if len(tag) > 0 {
 		if sep := strings.IndexRune(tag, ' '); sep >= 0 {
 			if sep == 0 {
 				return mode, "", fmt.Errorf("goxic imap: tag format '%s'", tag)
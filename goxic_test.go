@@ -3,14 +3,14 @@
 package goxic
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
-
-	"github.com/stvp/assert"
 )
 
 func assertIndices(t *testing.T, got []int, expect ...int) {
@@ -29,6 +29,13 @@ func assertIndices(t *testing.T, got []int, expect ...int) {
 	}
 }
 
+func assertEqual(t *testing.T, expect, got interface{}, hints ...interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected %v, got %v %s", expect, got, fmt.Sprint(hints...))
+	}
+}
+
 func mustStr(str string) string {
 	if len(str) > 0 {
 		return str
@@ -39,42 +46,42 @@ func mustStr(str string) string {
 
 func TestEmptyTemplate(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
-	assert.Equal(t, 0, tmpl.FixCount())
-	assert.Equal(t, 0, len(tmpl.Phs()))
+	assertEqual(t, 0, tmpl.FixCount())
+	assertEqual(t, 0, len(tmpl.Phs()))
 }
 
 func TestOneFix(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.AddStr("Fixate")
-	assert.Equal(t, 1, tmpl.FixCount())
-	assert.Equal(t, 0, len(tmpl.Phs()))
-	assert.Equal(t, "Fixate", string(tmpl.FixAt(0)))
+	assertEqual(t, 1, tmpl.FixCount())
+	assertEqual(t, 0, len(tmpl.Phs()))
+	assertEqual(t, "Fixate", string(tmpl.FixAt(0)))
 }
 
 func TestMergeFix(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.AddStr("<thisisfix1>")
 	tmpl.AddStr("<thisisfix2>")
-	assert.Equal(t, 1, tmpl.FixCount())
-	assert.Equal(t, "<thisisfix1><thisisfix2>", string(tmpl.FixAt(0)))
+	assertEqual(t, 1, tmpl.FixCount())
+	assertEqual(t, "<thisisfix1><thisisfix2>", string(tmpl.FixAt(0)))
 }
 
 func TestLeadingPlaceholder(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.Ph("foo")
 	tmpl.AddFix(fragment("bar"))
-	assert.Equal(t, "foo", mustStr(tmpl.PhAt(0)), "placeholder")
+	assertEqual(t, "foo", mustStr(tmpl.PhAt(0)), "placeholder")
 	assertIndices(t, tmpl.PhIdxs("foo"), 0)
-	assert.Equal(t, "bar", string(tmpl.FixAt(0)), "fix")
+	assertEqual(t, "bar", string(tmpl.FixAt(0)), "fix")
 }
 
 func TestTrailingPlaceholder(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.AddFix(fragment("foo"))
 	tmpl.Ph("bar")
-	assert.Equal(t, "bar", mustStr(tmpl.PhAt(1)), "placeholder")
+	assertEqual(t, "bar", mustStr(tmpl.PhAt(1)), "placeholder")
 	assertIndices(t, tmpl.PhIdxs("bar"), 1)
-	assert.Equal(t, "foo", string(tmpl.FixAt(0)), "fix")
+	assertEqual(t, "foo", string(tmpl.FixAt(0)), "fix")
 }
 
 func TestMidPlaceholder(t *testing.T) {
@@ -82,8 +89,8 @@ func TestMidPlaceholder(t *testing.T) {
 	tmpl.AddFix(fragment("foo"))
 	tmpl.Ph("bar")
 	tmpl.AddFix(fragment("baz"))
-	assert.Equal(t, "foo", string(tmpl.FixAt(0)), "fix")
-	assert.Equal(t, "baz", string(tmpl.FixAt(1)), "fix")
+	assertEqual(t, "foo", string(tmpl.FixAt(0)), "fix")
+	assertEqual(t, "baz", string(tmpl.FixAt(1)), "fix")
 	assertIndices(t, tmpl.PhIdxs("bar"), 1)
 }
 
@@ -91,7 +98,7 @@ func TestTwoPlaceholders(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.Ph("foo")
 	tmpl.Ph("bar")
-	assert.Equal(t, 1, tmpl.FixCount(), "fixed fragments")
+	assertEqual(t, 1, tmpl.FixCount(), "fixed fragments")
 	assertIndices(t, tmpl.PhIdxs("foo"), 0)
 	assertIndices(t, tmpl.PhIdxs("bar"), 1)
 }
@@ -103,20 +110,22 @@ func TestCatchEmit(t *testing.T) {
 	tmpl.AddStr("end")
 	bt := tmpl.NewBounT(nil)
 	bt.BindGenName("foo", func(wr io.Writer) int {
-		panic(EmitError{4711, errors.New("fails")})
+		panic(EmitError{Count: 4711, Err: errors.New("fails")})
 	})
 	n, err := CatchEmit(bt, os.Stdout)
-	assert.NotNil(t, err)
-	assert.Equal(t, 4711, n)
-	assert.Equal(t, "fails", err.Error())
+	if err == nil {
+		t.Fatal("expected emit error")
+	}
+	assertEqual(t, 4711, n)
+	assertEqual(t, "fails", err.Error())
 }
 
 func TestAnonymousBindFails(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	tmpl.AddStr("foo")
 	bt := tmpl.NewBounT(nil)
-	err := bt.BindP([]int{0}, 4711)
-	assert.NotNil(t, err)
+	anon := bt.BindP([]int{0}, 4711)
+	assertEqual(t, 1, anon)
 }
 
 func ExampleBounT() {
@@ -134,14 +143,14 @@ func ExampleBounT() {
 	// FOO<thisisfix1>FOO<thisisfix2>BAR
 }
 
-func ExampleDynamicContent() {
+func ExampleGenerator() {
 	ts := "2017-11-11 19:18:49"
 	tmpl := NewTemplate("")
 	tmpl.AddStr("It's now ").Ph("timestamp")
 	bt := tmpl.NewBounT(nil)
 	bt.BindName("timestamp", Generator(func(wr io.Writer) int {
 		if n, err := fmt.Fprint(wr, ts); err != nil {
-			panic(EmitError{n, err})
+			panic(EmitError{Count: n, Err: err})
 		} else {
 			return n
 		}
@@ -151,7 +160,7 @@ func ExampleDynamicContent() {
 	// It's now 2017-11-11 19:18:49
 }
 
-func ExampleFixate() {
+func ExampleBounT_Fixate() {
 	tr := NewTemplate("root").AddStr("foo").Ph("bar").AddStr("baz")
 	tn := NewTemplate("sub").AddStr("N-TMPL").Ph("quux")
 	bt := tr.NewBounT(nil)
@@ -239,22 +248,76 @@ func ExampleTemplate_Wrap() {
 	// foo<[BAR]>baz
 }
 
-// Would be nice to work…
-//type GxtNest struct {
-//	*Template
-//	Id []int
-//}
+func TestBounT_Fixate_static(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).AddStr("foo").Ph("bar").AddStr("baz")
+	bt := tmpl.NewInitBounT(Print{"-"}, nil)
+	ft := bt.Fixate()
+	if ft == nil {
+		t.Fatal("no fixated template")
+	}
+	static, ok := ft.Static()
+	if !ok {
+		t.Fatal("fixated template is not static")
+	}
+	assertEqual(t, "foo-baz", string(static))
+	static, ok = NewTemplate("nophs").AddStr("fix").NewBounT(nil).Fixate().Static()
+	assertEqual(t, "fix", string(static))
+	assertEqual(t, true, ok)
+}
+
+func TestBounT_FixateWith(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).Ph("a").AddStr("|").Ph("b").AddStr("|").Ph("c")
+	tmpl.WrapName("upper", tmpl.PhIdxs("c")...)
+	sub := NewTemplate("sub").AddStr("<").Ph("c").AddStr(">")
+	sub.WrapName("upper", sub.PhIdxs("c")...)
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("a", "A")
+	bt.BindName("b", sub.NewBounT(nil))
+	ft := bt.FixateWith(FixKeepName, "b")
+	assertEqual(t, []string{"a", "c"}, sortedPhs(ft))
+	assertIndices(t, ft.PhIdxs("c"), 1, 2)
+	assertEqual(t, []string{"upper"}, ft.WrapNamesAt(1))
+	assertEqual(t, []string{"upper"}, ft.WrapNamesAt(2))
+	fbt := ft.NewBounT(nil)
+	fbt.BindPName("a", "a")
+	fbt.BindPName("c", "c")
+	assertEqual(t, "a|<C>|C", emitString(t, fbt))
+}
+
+func sortedPhs(t *Template) []string {
+	res := t.Phs()
+	sort.Strings(res)
+	return res
+}
+
+// flushRecorder records the output written up to each flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushed []string
+	err     error
+}
 
-//type gxtFinal struct {
-//	GxtNest
-//	Name []int
-//}
+func (fr *flushRecorder) Flush() error {
+	fr.flushed = append(fr.flushed, fr.String())
+	return fr.err
+}
 
-//func TestIndexMap_nest(t *testing.T) {
-//	tmpl := NewTemplate(t.Name())
-//	tmpl.Placeholder("Id")
-//	tmpl.Placeholder("Name")
-//	var imap gxtFinal
-//	unmapped := InitIndexMap(&imap, tmpl, IdName)
-//	assert.Equal(t, 0, len(unmapped.Placeholders), unmapped.Placeholders)
-//}
+func TestBounT_Emit_flush(t *testing.T) {
+	tmpl := NewTemplate("page").AddStr("<head>").Flush().AddStr("<body>")
+	tmpl.Ph("main").Flush().AddStr("</body>").Flush()
+	assertEqual(t, 3, tmpl.FixCount())
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("main", "MAIN")
+	var fr flushRecorder
+	bt.Emit(&fr)
+	assertEqual(t,
+		[]string{"<head>", "<head><body>MAIN", "<head><body>MAIN</body>"},
+		fr.flushed)
+	fr = flushRecorder{err: errors.New("flush failed")}
+	if _, err := CatchEmit(bt, &fr); err != fr.err {
+		t.Errorf("unexpected error %v", err)
+	}
+	static, ok := NewTemplate("static").AddStr("a").Flush().AddStr("b").Static()
+	assertEqual(t, true, ok)
+	assertEqual(t, "ab", string(static))
+}
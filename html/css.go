@@ -0,0 +1,254 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func init() {
+	goxic.RegisterWrapper("css", CSSValueWrap)
+	goxic.RegisterWrapper("cssstr", CSSStringWrap)
+	goxic.RegisterWrapper("cssident", CSSIdentWrap)
+	goxic.RegisterWrapper("cssurl", CSSURLWrap)
+}
+
+// CSSEscWriter escapes everything written to it with CSS escapes, e.g.
+// `\22 ` for '"'. With Ident unset the output is meant to be used in CSS
+// strings. With Ident set the output is a CSS identifier, i.e. also
+// blanks and a leading digit are escaped. Non-ASCII characters are kept,
+// invalid UTF-8 is replaced with U+FFFD. The output can be embedded into
+// <style> elements and style attributes.
+type CSSEscWriter struct {
+	Escape  io.Writer
+	Ident   bool
+	buf     [utf8.UTFMax]byte
+	wp      int
+	started bool
+}
+
+func (cw *CSSEscWriter) Write(p []byte) (n int, err error) {
+	var out []byte
+	for len(p) > 0 {
+		if cw.wp > 0 || !utf8.FullRune(p) {
+			for len(p) > 0 && cw.wp < len(cw.buf) && !utf8.FullRune(cw.buf[:cw.wp]) {
+				cw.buf[cw.wp] = p[0]
+				cw.wp++
+				p = p[1:]
+			}
+			if !utf8.FullRune(cw.buf[:cw.wp]) {
+				break
+			}
+			r, sz := utf8.DecodeRune(cw.buf[:cw.wp])
+			out = cw.escRune(out, r, sz)
+			cw.wp = copy(cw.buf[:], cw.buf[sz:cw.wp])
+			continue
+		}
+		r, sz := utf8.DecodeRune(p)
+		out = cw.escRune(out, r, sz)
+		p = p[sz:]
+	}
+	if len(out) > 0 {
+		n, err = cw.Escape.Write(out)
+	}
+	return n, err
+}
+
+// Close replaces an incomplete UTF-8 sequence that is left at the end of
+// the content. It does not close Escape.
+func (cw *CSSEscWriter) Close() error {
+	_, err := cw.end()
+	return err
+}
+
+// Flush flushes Escape like EscWriter.Flush.
+func (cw *CSSEscWriter) Flush() error { return goxic.Flush(cw.Escape) }
+
+func (cw *CSSEscWriter) end() (n int, err error) {
+	var out []byte
+	for ; cw.wp > 0; cw.wp-- {
+		out = cw.escRune(out, utf8.RuneError, 1)
+	}
+	if len(out) > 0 {
+		n, err = cw.Escape.Write(out)
+	}
+	return n, err
+}
+
+func (cw *CSSEscWriter) escRune(out []byte, r rune, sz int) []byte {
+	first := !cw.started
+	cw.started = true
+	switch {
+	case r == utf8.RuneError && sz == 1:
+		r = utf8.RuneError
+	case r >= 0x80 && r != 0x2028 && r != 0x2029:
+		return utf8.AppendRune(out, r)
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', r == '_', r == '-':
+		return append(out, byte(r))
+	case '0' <= r && r <= '9':
+		if !(cw.Ident && first) {
+			return append(out, byte(r))
+		}
+	case r == ' ' || r == '.' || r == ',' || r == '#' || r == '%' || r == '/' || r == ':':
+		if !cw.Ident {
+			return append(out, byte(r))
+		}
+	}
+	out = append(out, '\\')
+	if r >= 0x10000 {
+		out = append(out, upperHex[r>>20&0xf], upperHex[r>>16&0xf])
+	}
+	if r >= 0x100 {
+		out = append(out, upperHex[r>>12&0xf], upperHex[r>>8&0xf])
+	}
+	return append(out, upperHex[r>>4&0xf], upperHex[r&0xf], ' ')
+}
+
+// CSSString emits Cnt escaped to be used within a quoted CSS string.
+type CSSString struct {
+	Cnt goxic.Content
+}
+
+func (cs CSSString) Emit(wr io.Writer) int {
+	return cs.EmitCtx(context.Background(), wr)
+}
+
+func (cs CSSString) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return emitEscaped(ctx, cs.Cnt, &CSSEscWriter{Escape: wr})
+}
+
+func (cs CSSString) Prestart(ctx context.Context) { goxic.Prestart(ctx, cs.Cnt) }
+
+func CSSStringWrap(c goxic.Content) goxic.Content { return CSSString{c} }
+
+// CSSIdent emits Cnt escaped as CSS identifier, e.g. a class name.
+type CSSIdent struct {
+	Cnt goxic.Content
+}
+
+func (ci CSSIdent) Emit(wr io.Writer) int {
+	return ci.EmitCtx(context.Background(), wr)
+}
+
+func (ci CSSIdent) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return emitEscaped(ctx, ci.Cnt, &CSSEscWriter{Escape: wr, Ident: true})
+}
+
+func (ci CSSIdent) Prestart(ctx context.Context) { goxic.Prestart(ctx, ci.Cnt) }
+
+func CSSIdentWrap(c goxic.Content) goxic.Content { return CSSIdent{c} }
+
+// UnsafeCSS replaces CSS values that are rejected by CSSValue.
+const UnsafeCSS = "invalid-goxic"
+
+var cssBlacklist = []string{
+	"expression", "javascript", "vbscript", "url", "image", "behavior",
+	"binding", "import", "attr", "env", "var",
+}
+
+// CSSValue emits the CSS value from Cnt, e.g. a colour or a length. Values
+// that contain other characters than letters, digits, blanks and
+// #%.,+-_()/ or that contain function names like expression or url are
+// replaced with UnsafeCSS. Use CSSURL for URLs.
+type CSSValue struct {
+	Cnt goxic.Content
+}
+
+func (cv CSSValue) Emit(wr io.Writer) int {
+	return cv.EmitCtx(context.Background(), wr)
+}
+
+func (cv CSSValue) EmitCtx(ctx context.Context, wr io.Writer) int {
+	var buf bytes.Buffer
+	goxic.EmitCtx(ctx, cv.Cnt, &buf)
+	val := buf.Bytes()
+	if !SafeCSSValue(string(val)) {
+		val = []byte(UnsafeCSS)
+	}
+	return goxic.Data(val).Emit(wr)
+}
+
+func (cv CSSValue) Prestart(ctx context.Context) { goxic.Prestart(ctx, cv.Cnt) }
+
+func CSSValueWrap(c goxic.Content) goxic.Content { return CSSValue{c} }
+
+// SafeCSSValue reports whether CSSValue emits val unchanged.
+func SafeCSSValue(val string) bool {
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte(" \t#%.,+-_()", c) >= 0:
+		case c == '/' && (i+1 == len(val) || val[i+1] != '*'):
+		default:
+			return false
+		}
+	}
+	lval := strings.ToLower(val)
+	for _, bad := range cssBlacklist {
+		for off := 0; ; {
+			idx := strings.Index(lval[off:], bad)
+			if idx < 0 {
+				break
+			}
+			idx += off
+			off = idx + len(bad)
+			if idx > 0 && cssNameChar(lval[idx-1]) {
+				continue
+			}
+			switch bad {
+			case "expression", "javascript", "vbscript":
+				return false
+			}
+			if strings.HasPrefix(strings.TrimLeft(lval[off:], " \t"), "(") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func cssNameChar(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_'
+}
+
+// CSSURL emits the URL from Cnt as CSS url("…"). URLs with a scheme that
+// is not in Schemes – DefaultSchemes if nil – are replaced by UnsafeURL.
+type CSSURL struct {
+	Cnt     goxic.Content
+	Schemes []string
+}
+
+func (cu CSSURL) Emit(wr io.Writer) int {
+	return cu.EmitCtx(context.Background(), wr)
+}
+
+func (cu CSSURL) EmitCtx(ctx context.Context, wr io.Writer) int {
+	var buf bytes.Buffer
+	goxic.EmitCtx(ctx, cu.Cnt, &buf)
+	schemes := cu.Schemes
+	if schemes == nil {
+		schemes = DefaultSchemes
+	}
+	url := buf.Bytes()
+	if !allowedScheme(url, schemes) {
+		url = []byte(UnsafeURL)
+	}
+	var out bytes.Buffer
+	out.WriteString(`url("`)
+	ewr := CSSEscWriter{Escape: &out}
+	ewr.Write(url)
+	ewr.Close()
+	out.WriteString(`")`)
+	return goxic.Data(out.Bytes()).Emit(wr)
+}
+
+func (cu CSSURL) Prestart(ctx context.Context) { goxic.Prestart(ctx, cu.Cnt) }
+
+func CSSURLWrap(c goxic.Content) goxic.Content { return CSSURL{Cnt: c} }
@@ -0,0 +1,98 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func TestCSSEscWriter(t *testing.T) {
+	for _, test := range []struct {
+		ident      bool
+		in, expect string
+	}{
+		{false, `a "b" </style>`, `a \22 b\22  \3C /style\3E `},
+		{false, "x\\y\n\u2028ä\xff", `x\5C y\0A \2028 ä\FFFD `},
+		{true, "1st class", `\31 st\20 class`},
+		{true, "a.b", `a\2E b`},
+	} {
+		var buf bytes.Buffer
+		ewr := CSSEscWriter{Escape: &buf, Ident: test.ident}
+		n, err := ewr.Write([]byte(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expect {
+			t.Errorf("%q: expected '%s', got '%s'", test.in, test.expect, buf.String())
+		}
+		if n != buf.Len() {
+			t.Errorf("%q: counted %d bytes, wrote %d", test.in, n, buf.Len())
+		}
+	}
+}
+
+func TestCSSString_incomplete(t *testing.T) {
+	var buf bytes.Buffer
+	n := CSSString{goxic.Print{V: "caf\xe2\x82"}}.Emit(&buf)
+	if s := buf.String(); s != `caf\FFFD \FFFD ` {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+}
+
+func TestSafeCSSValue(t *testing.T) {
+	for val, safe := range map[string]bool{
+		"#ff8000":                  true,
+		"rgb(10, 20, 30)":          true,
+		"1.5em solid red":          true,
+		"calc(100% - 2px)":         true,
+		"16px/1.2 sans-serif":      true,
+		"background-image":         true,
+		"expression(alert(1))":     false,
+		"EXPRESSION (alert(1))":    false,
+		"url(javascript:alert(1))": false,
+		"URL (x)":                  false,
+		"red;background:url(x)":    false,
+		"red}body{color:blue":      false,
+		`"quoted"`:                 false,
+		"a/*comment*/b":            false,
+		`\65 xpression(alert(1))`:  false,
+		"var(--x)":                 false,
+	} {
+		if got := SafeCSSValue(val); got != safe {
+			t.Errorf("%q: expected safe=%t", val, safe)
+		}
+	}
+}
+
+func TestCSSURL(t *testing.T) {
+	var buf bytes.Buffer
+	CSSURL{Cnt: goxic.Print{V: "javascript:alert(1)"}}.Emit(&buf)
+	if s := buf.String(); s != `url("about:invalid#goxic")` {
+		t.Errorf("unexpected '%s'", s)
+	}
+}
+
+func ExampleCSSValue() {
+	tmpl := goxic.NewTemplate("theme").
+		AddStr("<style>body { color: ").PhWrap("fg", CSSValueWrap).
+		AddStr("; background: ").PhWrap("bg", CSSURLWrap).
+		AddStr("; }\n.").PhWrap("cls", CSSIdentWrap).
+		AddStr(" { content: \"").PhWrap("txt", CSSStringWrap).
+		AddStr("\"; }</style>")
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("fg", "expression(alert(1))")
+	bt.BindPName("bg", "/img/bg.png")
+	bt.BindPName("cls", "2col")
+	bt.BindPName("txt", `"</style>`)
+	bt.Emit(os.Stdout)
+	// Output:
+	// <style>body { color: invalid-goxic; background: url("/img/bg.png"); }
+	// .\32 col { content: "\22 \3C /style\3E "; }</style>
+}
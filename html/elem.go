@@ -0,0 +1,211 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+// Attr is an attribute of an HTML element. Boolean attributes, e.g.
+// disabled, are written without value.
+type Attr struct {
+	Name  string
+	Value string
+	Bool  bool
+}
+
+// Elem is content that emits an HTML element with its attributes in the
+// order they were set and its child content. Attribute values are
+// escaped, children are escaped unless they are trusted, see IsTrusted.
+// This is also true for children of script or style elements, i.e. wrap
+// e.g. JSON into Trusted there. Void elements, e.g. br or img, are written
+// without end tag and must not have children.
+type Elem struct {
+	Tag      string
+	Attrs    []Attr
+	Children []goxic.Content
+}
+
+var voidElems = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// IsVoid reports whether tag is the name of a void element.
+func IsVoid(tag string) bool { return voidElems[strings.ToLower(tag)] }
+
+func NewElem(tag string, children ...goxic.Content) *Elem {
+	return &Elem{Tag: tag, Children: children}
+}
+
+func (e *Elem) attrIdx(name string) int {
+	for i := range e.Attrs {
+		if strings.EqualFold(e.Attrs[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Attr sets the attribute name to value. An attribute that is already set
+// keeps its position.
+func (e *Elem) Attr(name, value string) *Elem {
+	if i := e.attrIdx(name); i >= 0 {
+		e.Attrs[i] = Attr{Name: name, Value: value}
+	} else {
+		e.Attrs = append(e.Attrs, Attr{Name: name, Value: value})
+	}
+	return e
+}
+
+// BoolAttr sets the boolean attribute name if on is true and removes it
+// otherwise.
+func (e *Elem) BoolAttr(name string, on bool) *Elem {
+	i := e.attrIdx(name)
+	switch {
+	case on && i >= 0:
+		e.Attrs[i] = Attr{Name: name, Bool: true}
+	case on:
+		e.Attrs = append(e.Attrs, Attr{Name: name, Bool: true})
+	case i >= 0:
+		e.Attrs = append(e.Attrs[:i], e.Attrs[i+1:]...)
+	}
+	return e
+}
+
+// GetAttr returns the value of attribute name and whether it is set.
+func (e *Elem) GetAttr(name string) (string, bool) {
+	if i := e.attrIdx(name); i >= 0 {
+		return e.Attrs[i].Value, true
+	}
+	return "", false
+}
+
+// AddClass adds the classes cls to the element's class attribute. Classes
+// the element already has are not added again.
+func (e *Elem) AddClass(cls ...string) *Elem {
+	cur, _ := e.GetAttr("class")
+	have := strings.Fields(cur)
+	for _, c := range cls {
+		for _, f := range strings.Fields(c) {
+			if !hasClass(have, f) {
+				have = append(have, f)
+			}
+		}
+	}
+	return e.Attr("class", strings.Join(have, " "))
+}
+
+func hasClass(cls []string, c string) bool {
+	for _, h := range cls {
+		if h == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends children to the element's content.
+func (e *Elem) Add(children ...goxic.Content) *Elem {
+	e.Children = append(e.Children, children...)
+	return e
+}
+
+// Text appends text to the element's content. Like all untrusted
+// children, the text is escaped when the element is emitted.
+func (e *Elem) Text(text string) *Elem {
+	return e.Add(goxic.Data(text))
+}
+
+func (*Elem) SafeHTML() {}
+
+func (e *Elem) Emit(wr io.Writer) int {
+	return e.EmitCtx(context.Background(), wr)
+}
+
+func (e *Elem) EmitCtx(ctx context.Context, wr io.Writer) (n int) {
+	if err := e.check(); err != nil {
+		panic(goxic.EmitError{Err: err})
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(e.Tag)
+	ewr := EscWriter{Escape: &buf, ReplaceInvalid: true}
+	for _, a := range e.Attrs {
+		buf.WriteByte(' ')
+		buf.WriteString(a.Name)
+		if !a.Bool {
+			buf.WriteString(`="`)
+			ewr.Write([]byte(a.Value))
+			ewr.Close()
+			buf.WriteByte('"')
+		}
+	}
+	buf.WriteByte('>')
+	n = goxic.Data(buf.Bytes()).Emit(wr)
+	if IsVoid(e.Tag) {
+		return n
+	}
+	for _, c := range e.Children {
+		if err := ctx.Err(); err != nil {
+			panic(goxic.EmitError{Count: n, Err: err})
+		}
+		n += ModeEscaper{Cnt: c, ReplaceInvalid: true}.EmitCtx(ctx, wr)
+	}
+	c, err := fmt.Fprintf(wr, "</%s>", e.Tag)
+	n += c
+	if err != nil {
+		panic(goxic.EmitError{Count: n, Err: err})
+	}
+	return n
+}
+
+// Prestart prestarts all children of e.
+func (e *Elem) Prestart(ctx context.Context) {
+	for _, c := range e.Children {
+		goxic.Prestart(ctx, c)
+	}
+}
+
+func (e *Elem) check() error {
+	if !validName(e.Tag) {
+		return fmt.Errorf("invalid element name '%s'", e.Tag)
+	}
+	if IsVoid(e.Tag) && len(e.Children) > 0 {
+		return fmt.Errorf("void element '%s' with content", e.Tag)
+	}
+	for _, a := range e.Attrs {
+		if !validName(a.Name) {
+			return fmt.Errorf("invalid attribute name '%s' in element '%s'",
+				a.Name,
+				e.Tag)
+		}
+	}
+	return nil
+}
+
+// validName checks names of elements and attributes. It is stricter than
+// the HTML spec and only allows ASCII letters, digits, '-', '_', ':' and
+// '.'. Names must start with a letter.
+func validName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && (('0' <= c && c <= '9') || strings.IndexByte("-_:.", c) >= 0):
+		default:
+			return false
+		}
+	}
+	return true
+}
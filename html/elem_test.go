@@ -0,0 +1,79 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func TestElem_count(t *testing.T) {
+	e := NewElem("p").
+		Attr("title", `"quoted" & <b>`).
+		Text("a < b").
+		Add(NewElem("br"), NewElem("em").Text("!"))
+	var buf bytes.Buffer
+	n := e.Emit(&buf)
+	const expect = `<p title="&quot;quoted&quot; &amp; &lt;b&gt;">a &lt; b<br><em>!</em></p>`
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+}
+
+func TestElem_untrusted(t *testing.T) {
+	var buf bytes.Buffer
+	Escaper{NewElem("b", goxic.Print{V: "<script>alert(1)</script>"},
+		TrustedString("<i>x</i>"))}.Emit(&buf)
+	const expect = `<b>&lt;script&gt;alert(1)&lt;/script&gt;<i>x</i></b>`
+	if s := buf.String(); s != expect {
+		t.Errorf("expected '%s', got '%s'", expect, s)
+	}
+}
+
+func TestElem_errors(t *testing.T) {
+	for _, e := range []*Elem{
+		NewElem("br").Text("x"),
+		NewElem("p x"),
+		NewElem("p").Attr(`on"click`, ""),
+		NewElem(""),
+	} {
+		if _, err := goxic.CatchEmit(goxic.NewTemplate("").Ph("e").NewInitBounT(e, nil),
+			&bytes.Buffer{}); err == nil {
+			t.Errorf("no error for element '%s'", e.Tag)
+		}
+	}
+}
+
+func TestSpan_count(t *testing.T) {
+	for _, span := range []*Span{
+		NewSpan(goxic.Print{V: "foo"}, "", ""),
+		NewSpan(goxic.Print{V: "foo"}, "id", ""),
+		NewSpan(goxic.Print{V: "foo"}, "", "cls"),
+		NewSpan(goxic.Print{V: "foo"}, "id", "cls"),
+	} {
+		var buf bytes.Buffer
+		if n := span.Emit(&buf); n != buf.Len() {
+			t.Errorf("counted %d bytes, wrote %d: %s", n, buf.Len(), buf.String())
+		}
+	}
+}
+
+func ExampleElem() {
+	e := NewElem("input").
+		Attr("type", "checkbox").
+		Attr("name", "agree").
+		BoolAttr("checked", true).
+		BoolAttr("disabled", true).
+		AddClass("big", "box").
+		AddClass("box red")
+	e.BoolAttr("disabled", false)
+	NewElem("label", e).Text(" I agree").Emit(os.Stdout)
+	// Output:
+	// <label><input type="checkbox" name="agree" checked class="big box red"> I agree</label>
+}
@@ -4,16 +4,26 @@ package html
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"unicode/utf8"
 
-	"git.fractalqb.de/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic"
 )
 
+func init() {
+	goxic.RegisterWrapper("html", EscWrap)
+	goxic.RegisterWrapper("attr", EscWrapWith(EscAttr, true))
+}
+
+// MediaType is the media type of templates parsed with NewParser.
+const MediaType = "text/html"
+
 func NewParser() *goxic.Parser {
-	res := goxic.NewParser("`", "`", "<!--", "-->")
+	res := goxic.NewSpecParser("`", "`", "<!--", "-->")
+	res.MediaType = MediaType
 	//	res := &goxic.Parser{
 	//		StartInlinePh: "`",
 	//		EndInlinePh:   "`",
@@ -34,66 +44,185 @@ func NewParser() *goxic.Parser {
 	return res
 }
 
+// EscMode selects the characters EscWriter escapes. All modes replace NUL
+// with U+FFFD.
+type EscMode int
+
+const (
+	// EscDefault escapes < > & " and '.
+	EscDefault EscMode = iota
+	// EscText only escapes < and & which is sufficient for text nodes.
+	EscText
+	// EscAttr escapes like EscDefault and additionally ` = and whitespace,
+	// which makes the output safe even for unquoted attribute values.
+	EscAttr
+)
+
+// EscWriter escapes HTML special characters written to it according to
+// Mode. By default, invalid UTF-8 makes Write fail. With ReplaceInvalid
+// set, invalid bytes are replaced with U+FFFD instead. Runs of bytes that
+// need no escaping are written with a single Write call. A rune that is
+// split across Write calls is buffered until it is complete. Call Close at
+// the end of the content to handle an incomplete rune that is left.
 type EscWriter struct {
-	Escape io.Writer
-	buf    [utf8.UTFMax]byte
-	wp     int
+	Escape         io.Writer
+	Mode           EscMode
+	ReplaceInvalid bool
+	buf            [utf8.UTFMax]byte
+	wp             int
+}
+
+var errInvalidUTF8 = errors.New("utf8 rune decoding error")
+
+func (hew *EscWriter) escASCII(c byte) string {
+	switch c {
+	case '\000':
+		return "\uFFFD"
+	case '<':
+		return "&lt;"
+	case '&':
+		return "&amp;"
+	}
+	if hew.Mode == EscText {
+		return ""
+	}
+	switch c {
+	case '>':
+		return "&gt;"
+	case '"':
+		return "&quot;"
+	case '\'':
+		return "&apos;"
+	}
+	if hew.Mode == EscAttr {
+		switch c {
+		case '`':
+			return "&#96;"
+		case '=':
+			return "&#61;"
+		case ' ':
+			return "&#32;"
+		case '\t':
+			return "&#9;"
+		case '\n':
+			return "&#10;"
+		case '\r':
+			return "&#13;"
+		case '\f':
+			return "&#12;"
+		}
+	}
+	return ""
+}
+
+// run returns the length of the prefix of p that needs no escaping.
+func (hew *EscWriter) run(p []byte) int {
+	i := 0
+	for i < len(p) {
+		if c := p[i]; c < utf8.RuneSelf {
+			if hew.escASCII(c) != "" {
+				return i
+			}
+			i++
+			continue
+		}
+		if !utf8.FullRune(p[i:]) {
+			return i
+		}
+		r, sz := utf8.DecodeRune(p[i:])
+		if r == utf8.RuneError && sz == 1 {
+			return i
+		}
+		i += sz
+	}
+	return i
+}
+
+func (hew *EscWriter) write(p []byte, n int) (int, error) {
+	i, err := hew.Escape.Write(p)
+	return n + i, err
 }
 
 func (hew *EscWriter) Write(p []byte) (n int, err error) {
-	for _, b := range p {
-		hew.buf[hew.wp] = b
+	for hew.wp > 0 && len(p) > 0 {
+		hew.buf[hew.wp] = p[0]
 		hew.wp++
-		if buf := hew.buf[:hew.wp]; utf8.FullRune(buf) {
+		p = p[1:]
+		if utf8.FullRune(hew.buf[:hew.wp]) {
+			rest := hew.buf[:hew.wp]
 			hew.wp = 0
-			if r, _ := utf8.DecodeRune(buf); r == utf8.RuneError {
-				return n, errors.New("utf8 rune decoding error")
-			} else {
-				switch r {
-				case '\000':
-					if i, err := hew.Escape.Write([]byte("\uFFFD")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				case '<':
-					if i, err := hew.Escape.Write([]byte("&lt;")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				case '>':
-					if i, err := hew.Escape.Write([]byte("&gt;")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				case '&':
-					if i, err := hew.Escape.Write([]byte("&amp;")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				case '"':
-					if i, err := hew.Escape.Write([]byte("&quot;")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				case '\'':
-					if i, err := hew.Escape.Write([]byte("&apos;")); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				default:
-					if i, err := hew.Escape.Write([]byte(string(r))); err != nil {
-						return n + i, err
-					} else {
-						n += i
-					}
-				}
+			i, err := hew.Write(rest)
+			if n += i; err != nil {
+				return n, err
+			}
+		}
+	}
+	for len(p) > 0 {
+		if i := hew.run(p); i > 0 {
+			if n, err = hew.write(p[:i], n); err != nil {
+				return n, err
 			}
+			p = p[i:]
+			continue
+		}
+		c := p[0]
+		switch {
+		case c < utf8.RuneSelf:
+			n, err = hew.write([]byte(hew.escASCII(c)), n)
+		case !utf8.FullRune(p):
+			hew.wp = copy(hew.buf[:], p)
+			return n, nil
+		case hew.ReplaceInvalid:
+			n, err = hew.write([]byte("\uFFFD"), n)
+		default:
+			return n, errInvalidUTF8
+		}
+		if err != nil {
+			return n, err
+		}
+		p = p[1:]
+	}
+	return n, nil
+}
+
+// escWriter is an escaping writer that has to be ended after the content
+// was written to it.
+type escWriter interface {
+	io.Writer
+	end() (int, error)
+}
+
+// emitEscaped emits cnt to ew and ends ew.
+func emitEscaped(ctx context.Context, cnt goxic.Content, ew escWriter) int {
+	n := goxic.EmitCtx(ctx, cnt, ew)
+	c, err := ew.end()
+	if n += c; err != nil {
+		panic(goxic.EmitError{Count: n, Err: err})
+	}
+	return n
+}
+
+// Close replaces an incomplete UTF-8 sequence that is left at the end of
+// the content or, without ReplaceInvalid, reports it as error. It does not
+// close Escape.
+func (hew *EscWriter) Close() error {
+	_, err := hew.end()
+	return err
+}
+
+// Flush flushes Escape, see goxic.Flush. An incomplete UTF-8 sequence is
+// kept until more content is written.
+func (hew *EscWriter) Flush() error { return goxic.Flush(hew.Escape) }
+
+func (hew *EscWriter) end() (n int, err error) {
+	if hew.wp > 0 && !hew.ReplaceInvalid {
+		hew.wp = 0
+		return 0, errInvalidUTF8
+	}
+	for ; hew.wp > 0; hew.wp-- {
+		if n, err = hew.write([]byte("\uFFFD"), n); err != nil {
+			hew.wp = 0
+			return n, err
 		}
 	}
 	return n, nil
@@ -105,6 +234,9 @@ func Esc(str string) string {
 	if _, err := ewr.Write([]byte(str)); err != nil {
 		panic(err)
 	}
+	if err := ewr.Close(); err != nil {
+		panic(err)
+	}
 	return buf.String()
 }
 
@@ -112,15 +244,52 @@ type Escaper struct {
 	Cnt goxic.Content
 }
 
+// Emit escapes the content unless it is trusted, see IsTrusted.
 func (hc Escaper) Emit(wr io.Writer) int {
-	esc := EscWriter{Escape: wr}
-	return hc.Cnt.Emit(&esc)
+	return hc.EmitCtx(context.Background(), wr)
+}
+
+func (hc Escaper) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return ModeEscaper{Cnt: hc.Cnt}.EmitCtx(ctx, wr)
 }
 
+func (hc Escaper) Prestart(ctx context.Context) { goxic.Prestart(ctx, hc.Cnt) }
+
 func EscWrap(c goxic.Content) goxic.Content {
 	return Escaper{c}
 }
 
+// ModeEscaper is like Escaper but escapes with Mode and replaces invalid
+// UTF-8 if ReplaceInvalid is set, see EscWriter. Like with Escaper,
+// trusted content is passed through unless Mode is EscAttr, i.e. attribute
+// values never contain markup.
+type ModeEscaper struct {
+	Cnt            goxic.Content
+	Mode           EscMode
+	ReplaceInvalid bool
+}
+
+func (me ModeEscaper) Emit(wr io.Writer) int {
+	return me.EmitCtx(context.Background(), wr)
+}
+
+func (me ModeEscaper) EmitCtx(ctx context.Context, wr io.Writer) int {
+	if me.Mode != EscAttr && IsTrusted(me.Cnt) {
+		return goxic.EmitCtx(ctx, me.Cnt, wr)
+	}
+	esc := EscWriter{Escape: wr, Mode: me.Mode, ReplaceInvalid: me.ReplaceInvalid}
+	return emitEscaped(ctx, me.Cnt, &esc)
+}
+
+func (me ModeEscaper) Prestart(ctx context.Context) { goxic.Prestart(ctx, me.Cnt) }
+
+// EscWrapWith returns a wrapper that wraps content into a ModeEscaper.
+func EscWrapWith(mode EscMode, replaceInvalid bool) goxic.CntWrapper {
+	return func(c goxic.Content) goxic.Content {
+		return ModeEscaper{Cnt: c, Mode: mode, ReplaceInvalid: replaceInvalid}
+	}
+}
+
 // Span wraps content into a HTML <span></span> element
 type Span struct {
 	id      string
@@ -133,33 +302,32 @@ func NewSpan(around goxic.Content, spanId string, spanClass string) *Span {
 	return &res
 }
 
-func (s *Span) Emit(wr io.Writer) (n int) {
-	if len(s.id) > 0 {
-		if len(s.class) > 0 {
-			n, err := fmt.Fprintf(wr,
-				"<span id=\"%s\" class=\"%s\">",
-				s.id,
-				s.class)
-			if err != nil {
-				panic(goxic.EmitError{n, err})
-			}
-		} else {
-			if n, err := fmt.Fprintf(wr, "<span id=\"%s\">", s.id); err != nil {
-				panic(goxic.EmitError{n, err})
-			}
-		}
-	} else if len(s.class) > 0 {
-		if n, err := fmt.Fprintf(wr, "<span class=\"%s\">", s.class); err != nil {
-			panic(goxic.EmitError{n, err})
-		}
-	} else if n, err := wr.Write([]byte("<span>")); err != nil {
-		panic(goxic.EmitError{n, err})
+func (s *Span) Emit(wr io.Writer) int {
+	return s.EmitCtx(context.Background(), wr)
+}
+
+func (s *Span) EmitCtx(ctx context.Context, wr io.Writer) (n int) {
+	var err error
+	switch {
+	case len(s.id) > 0 && len(s.class) > 0:
+		n, err = fmt.Fprintf(wr, "<span id=\"%s\" class=\"%s\">", s.id, s.class)
+	case len(s.id) > 0:
+		n, err = fmt.Fprintf(wr, "<span id=\"%s\">", s.id)
+	case len(s.class) > 0:
+		n, err = fmt.Fprintf(wr, "<span class=\"%s\">", s.class)
+	default:
+		n, err = wr.Write([]byte("<span>"))
 	}
-	n += s.Wrapped.Emit(wr)
+	if err != nil {
+		panic(goxic.EmitError{Count: n, Err: err})
+	}
+	n += goxic.EmitCtx(ctx, s.Wrapped, wr)
 	if c, err := wr.Write([]byte("</span>")); err != nil {
-		panic(goxic.EmitError{n + c, err})
+		panic(goxic.EmitError{Count: n + c, Err: err})
 	} else {
 		n += c
 	}
 	return n
 }
+
+func (s *Span) Prestart(ctx context.Context) { goxic.Prestart(ctx, s.Wrapped) }
@@ -4,30 +4,45 @@ package html
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
-	"git.fractalqb.de/fractalqb/goxic"
-	"github.com/stvp/assert"
+	"codeberg.org/fractalqb/goxic"
 )
 
 func TestHtmlEscWriter_Write(t *testing.T) {
 	buf := bytes.NewBuffer(nil)
 	ewr := EscWriter{Escape: buf}
 	n, err := ewr.Write([]byte("<>&\"'"))
-	assert.Nil(t, err, "have error: ", err)
-	assert.Equal(t, 25, n, "expected bytes written")
-	assert.Equal(t, "&lt;&gt;&amp;&quot;&apos;", buf.String(), "wrong output")
+	if err != nil {
+		t.Fatal("have error: ", err)
+	}
+	if n != 25 {
+		t.Errorf("expected 25 bytes written, got %d", n)
+	}
+	if s := buf.String(); s != "&lt;&gt;&amp;&quot;&apos;" {
+		t.Errorf("wrong output: '%s'", s)
+	}
 }
 
 func TestHtmlEscWriter_umls(t *testing.T) {
 	buf := bytes.NewBuffer(nil)
 	ewr := EscWriter{Escape: buf}
 	n, err := ewr.Write([]byte("öäüß"))
-	assert.Nil(t, err, "have error: ", err)
-	assert.Equal(t, 8, n, "expected bytes written")
-	assert.Equal(t, "öäüß", buf.String(), "wrong output")
+	if err != nil {
+		t.Fatal("have error: ", err)
+	}
+	if n != 8 {
+		t.Errorf("expected 8 bytes written, got %d", n)
+	}
+	if s := buf.String(); s != "öäüß" {
+		t.Errorf("wrong output: '%s'", s)
+	}
 }
 
 func BenchmarkHtmlEscWriter_umls(b *testing.B) {
@@ -40,11 +55,11 @@ func BenchmarkHtmlEscWriter_umls(b *testing.B) {
 	}
 }
 
-func ExampleEscape() {
+func ExampleEscaper() {
 	tmpl := goxic.NewTemplate("")
 	tmpl.Ph("html")
 	bt := tmpl.NewBounT(nil)
-	bt.BindName("html", Escaper{goxic.Print{"<&\"'>"}})
+	bt.BindName("html", Escaper{goxic.Print{V: "<&\"'>"}})
 	bt.Emit(os.Stdout)
 	// Output:
 	// &lt;&amp;&quot;&apos;&gt;
@@ -52,7 +67,7 @@ func ExampleEscape() {
 
 func ExampleSpan() {
 	out := os.Stdout
-	cnt := goxic.Print{"foo"}
+	cnt := goxic.Print{V: "foo"}
 	span := NewSpan(cnt, "", "")
 	span.Emit(out)
 	fmt.Fprintln(out)
@@ -71,3 +86,257 @@ func ExampleSpan() {
 	// <span class="&apos;CLS">foo</span>
 	// <span id="&amp;ID" class="&apos;CLS">foo</span>
 }
+
+func ExampleNewParser_wrapper() {
+	ts := make(map[string]*goxic.Template)
+	err := NewParser().Parse(
+		strings.NewReader("<title>`title|html`</title>"),
+		"page",
+		ts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("title", "Tom & Jerry")
+	bt.Emit(os.Stdout)
+	// Output:
+	// <title>Tom &amp; Jerry</title>
+}
+
+func TestHtmlEscWriter_invalid(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	ewr := EscWriter{Escape: buf}
+	if _, err := ewr.Write([]byte("caf\xe9!")); err == nil {
+		t.Error("expected error for invalid UTF-8")
+	}
+	buf.Reset()
+	ewr = EscWriter{Escape: buf, ReplaceInvalid: true}
+	n, err := ewr.Write([]byte("caf\xe9 <\xff>"))
+	if err != nil {
+		t.Fatal("have error: ", err)
+	}
+	if s := buf.String(); s != "caf� &lt;�&gt;" {
+		t.Errorf("wrong output: '%s'", s)
+	}
+	if n != buf.Len() {
+		t.Errorf("expected %d bytes written, got %d", buf.Len(), n)
+	}
+}
+
+func phBounT(cnt goxic.Content) *goxic.BounT {
+	return goxic.NewTemplate("ph").Ph("x").NewInitBounT(cnt, nil)
+}
+
+func TestHtmlEscWriter_invalidEnd(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	ewr := EscWriter{Escape: buf}
+	if _, err := ewr.Write([]byte("caf\xe9")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ewr.Close(); err == nil {
+		t.Error("expected error for incomplete UTF-8 at end")
+	}
+	buf.Reset()
+	ewr = EscWriter{Escape: buf, ReplaceInvalid: true}
+	ewr.Write([]byte("caf\xe9"))
+	if err := ewr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "caf\uFFFD" {
+		t.Errorf("wrong output: '%s'", s)
+	}
+	for _, test := range []struct {
+		cnt    goxic.Content
+		expect string
+	}{
+		{ModeEscaper{Cnt: goxic.Data("caf\xe9"), ReplaceInvalid: true}, "caf\uFFFD"},
+		{NewElem("p").Attr("title", "caf\xe9"), "<p title=\"caf\uFFFD\"></p>"},
+		{Sanitized{Cnt: goxic.Data("<b>caf\xe9</b>caf\xe9")}, "<b>caf\uFFFD</b>caf\uFFFD"},
+	} {
+		buf.Reset()
+		n, err := goxic.CatchEmit(phBounT(test.cnt), buf)
+		if err != nil {
+			t.Errorf("%T: %s", test.cnt, err)
+		} else if s := buf.String(); s != test.expect {
+			t.Errorf("%T: expected '%s', got '%s'", test.cnt, test.expect, s)
+		} else if n != buf.Len() {
+			t.Errorf("%T: counted %d bytes, wrote %d", test.cnt, n, buf.Len())
+		}
+	}
+	if _, err := goxic.CatchEmit(phBounT(Escaper{goxic.Data("caf\xe9")}), buf); err == nil {
+		t.Error("Escaper: expected error for incomplete UTF-8 at end")
+	}
+}
+
+func TestHtmlEscWriter_modes(t *testing.T) {
+	const in = "a<b & \"c\"='d'\x00`"
+	for _, test := range []struct {
+		mode EscMode
+		out  string
+	}{
+		{EscDefault, "a&lt;b &amp; &quot;c&quot;=&apos;d&apos;�`"},
+		{EscText, "a&lt;b &amp; \"c\"='d'�`"},
+		{EscAttr, "a&lt;b&#32;&amp;&#32;&quot;c&quot;&#61;&apos;d&apos;�&#96;"},
+	} {
+		buf := bytes.NewBuffer(nil)
+		ewr := EscWriter{Escape: buf, Mode: test.mode}
+		if _, err := ewr.Write([]byte(in)); err != nil {
+			t.Fatal("have error: ", err)
+		}
+		if s := buf.String(); s != test.out {
+			t.Errorf("mode %d: wrong output: '%s'", test.mode, s)
+		}
+	}
+}
+
+func TestHtmlEscWriter_split(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	ewr := EscWriter{Escape: buf}
+	in := []byte("ä€<")
+	n := 0
+	for i := range in {
+		c, err := ewr.Write(in[i : i+1])
+		if err != nil {
+			t.Fatal("have error: ", err)
+		}
+		n += c
+	}
+	if s := buf.String(); s != "ä€&lt;" {
+		t.Errorf("wrong output: '%s'", s)
+	}
+	if n != buf.Len() {
+		t.Errorf("expected %d bytes written, got %d", buf.Len(), n)
+	}
+}
+
+type countWriter struct {
+	bytes.Buffer
+	calls int
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	cw.calls++
+	return cw.Buffer.Write(p)
+}
+
+func TestHtmlEscWriter_runs(t *testing.T) {
+	var cw countWriter
+	ewr := EscWriter{Escape: &cw}
+	if _, err := ewr.Write([]byte("Tom & Jerry – Süße")); err != nil {
+		t.Fatal("have error: ", err)
+	}
+	if cw.calls != 3 {
+		t.Errorf("expected 3 write calls, got %d", cw.calls)
+	}
+}
+
+func ExampleNewParser_attr() {
+	ts := make(map[string]*goxic.Template)
+	err := NewParser().Parse(
+		strings.NewReader("<input value=`value|attr`>"),
+		"form",
+		ts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("value", "x onclick=alert(1)")
+	bt.Emit(os.Stdout)
+	// Output:
+	// <input value=x&#32;onclick&#61;alert(1)>
+}
+
+func TestEscaper_EmitCtx(t *testing.T) {
+	type key struct{}
+	tmpl := goxic.NewTemplate("").Ph("a").AddStr("|").Ph("b")
+	bt := tmpl.NewBounT(nil)
+	gen := goxic.GeneratorCtx(func(ctx context.Context, wr io.Writer) int {
+		return goxic.Print{V: ctx.Value(key{})}.Emit(wr)
+	})
+	bt.BindName("a", Escaper{gen})
+	bt.BindName("b", EscWrapWith(EscAttr, true)(gen))
+	var buf bytes.Buffer
+	bt.EmitCtx(context.WithValue(context.Background(), key{}, "<a b>"), &buf)
+	if s := buf.String(); s != "&lt;a b&gt;|&lt;a&#32;b&gt;" {
+		t.Errorf("wrong output: '%s'", s)
+	}
+}
+
+var testWraps = map[string]func(goxic.Content) goxic.Content{
+	"js":       JSStringWrap,
+	"cssstr":   CSSStringWrap,
+	"cssval":   CSSValueWrap,
+	"urlpath":  URLPathWrap,
+	"safeurl":  SafeURLWrap,
+	"sanitize": SanitizeWrap,
+	"elem":     func(c goxic.Content) goxic.Content { return NewElem("p", c) },
+	"span":     func(c goxic.Content) goxic.Content { return NewSpan(c, "", "") },
+}
+
+func TestWrappers_cancel(t *testing.T) {
+	for name, wrap := range testWraps {
+		ctx, cancel := context.WithCancel(context.Background())
+		inner := goxic.NewTemplate("").Ph("a").Ph("b").NewBounT(nil)
+		inner.BindGenCtxName("a", func(ctx context.Context, wr io.Writer) int {
+			cancel()
+			return goxic.Print{V: "a"}.Emit(wr)
+		})
+		inner.BindPName("b", "late")
+		var buf bytes.Buffer
+		_, err := goxic.CatchEmitCtx(ctx, phBounT(wrap(inner)), &buf)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if strings.Contains(buf.String(), "late") {
+			t.Errorf("%s: emitted after cancel: '%s'", name, buf.String())
+		}
+		cancel()
+	}
+}
+
+type prestartCount int
+
+func (pc *prestartCount) Emit(wr io.Writer) int { return 0 }
+
+func (pc *prestartCount) Prestart(ctx context.Context) { *pc++ }
+
+func TestWrappers_prestart(t *testing.T) {
+	for name, wrap := range testWraps {
+		var count prestartCount
+		phBounT(wrap(&count)).Emit(io.Discard)
+		if count != 1 {
+			t.Errorf("%s: prestarted %d times", name, count)
+		}
+	}
+}
+
+// flushRecorder records the output written up to each flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushed []string
+}
+
+func (fr *flushRecorder) Flush() { fr.flushed = append(fr.flushed, fr.String()) }
+
+func TestWrappers_flush(t *testing.T) {
+	// The sanitizer only flushes complete tokens, i.e. text ends with a tag
+	inner := goxic.NewTemplate("inner").AddStr("x<i>").Flush().AddStr("y")
+	for name, wrap := range map[string]func(goxic.Content) goxic.Content{
+		"html":     EscWrap,
+		"attr":     EscWrapWith(EscAttr, true),
+		"js":       JSStringWrap,
+		"cssstr":   CSSStringWrap,
+		"urlpath":  URLPathWrap,
+		"sanitize": SanitizeWrap,
+		"elem":     testWraps["elem"],
+	} {
+		var fr flushRecorder
+		phBounT(wrap(inner.NewBounT(nil))).Emit(&fr)
+		if len(fr.flushed) != 1 || !strings.Contains(fr.flushed[0], "x") ||
+			strings.Contains(fr.flushed[0], "y") {
+			t.Errorf("%s: unexpected flushes %q of '%s'", name, fr.flushed, fr.String())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func init() {
+	goxic.RegisterWrapper("js", JSStringWrap)
+}
+
+// JSON emits the JSON encoding of V. The characters '<', '>', '&', U+2028
+// and U+2029 are escaped, which makes the output safe to embed into
+// <script> elements, e.g. as JavaScript expression or as data island with
+// type="application/json". JSON is no SafeHTML because it is not safe in
+// other places, e.g. in quoted attribute values.
+type JSON struct {
+	V interface{}
+}
+
+func (j JSON) Emit(wr io.Writer) int {
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		panic(goxic.EmitError{Err: err})
+	}
+	return goxic.Data(data).Emit(wr)
+}
+
+// JSEscWriter escapes everything written to it to be used in a JavaScript
+// string literal, no matter if quoted with ', " or `. The output can be
+// embedded into <script> elements and HTML attribute values. Invalid
+// UTF-8 is replaced with U+FFFD.
+type JSEscWriter struct {
+	Escape io.Writer
+	buf    [utf8.UTFMax]byte
+	wp     int
+}
+
+func (jw *JSEscWriter) Write(p []byte) (n int, err error) {
+	var out []byte
+	for len(p) > 0 {
+		if jw.wp > 0 || !utf8.FullRune(p) {
+			for len(p) > 0 && jw.wp < len(jw.buf) && !utf8.FullRune(jw.buf[:jw.wp]) {
+				jw.buf[jw.wp] = p[0]
+				jw.wp++
+				p = p[1:]
+			}
+			if !utf8.FullRune(jw.buf[:jw.wp]) {
+				break
+			}
+			r, sz := utf8.DecodeRune(jw.buf[:jw.wp])
+			out = jsEscRune(out, r, sz)
+			rest := copy(jw.buf[:], jw.buf[sz:jw.wp])
+			jw.wp = rest
+			continue
+		}
+		r, sz := utf8.DecodeRune(p)
+		out = jsEscRune(out, r, sz)
+		p = p[sz:]
+	}
+	if len(out) > 0 {
+		n, err = jw.Escape.Write(out)
+	}
+	return n, err
+}
+
+// Close replaces an incomplete UTF-8 sequence that is left at the end of
+// the content. It does not close Escape.
+func (jw *JSEscWriter) Close() error {
+	_, err := jw.end()
+	return err
+}
+
+// Flush passes a flush on to Escape. A split rune stays buffered.
+func (jw *JSEscWriter) Flush() error { return goxic.Flush(jw.Escape) }
+
+func (jw *JSEscWriter) end() (n int, err error) {
+	var out []byte
+	for ; jw.wp > 0; jw.wp-- {
+		out = jsEscRune(out, utf8.RuneError, 1)
+	}
+	if len(out) > 0 {
+		n, err = jw.Escape.Write(out)
+	}
+	return n, err
+}
+
+func jsEscRune(out []byte, r rune, sz int) []byte {
+	switch {
+	case r == utf8.RuneError && sz == 1:
+		return append(out, `\uFFFD`...)
+	case r == '\\' || r == '`':
+		return append(out, '\\', byte(r))
+	case r == '\n':
+		return append(out, `\n`...)
+	case r == '\r':
+		return append(out, `\r`...)
+	case r == '\t':
+		return append(out, `\t`...)
+	case r < ' ', r == '<', r == '>', r == '&', r == '$', r == '\'', r == '"',
+		r == 0x7f, r == 0x2028, r == 0x2029:
+		return append(out, '\\', 'u',
+			upperHex[r>>12&0xf], upperHex[r>>8&0xf], upperHex[r>>4&0xf], upperHex[r&0xf])
+	}
+	return utf8.AppendRune(out, r)
+}
+
+// JSString emits Cnt escaped with JSEscWriter to be used in JavaScript
+// string literals.
+type JSString struct {
+	Cnt goxic.Content
+}
+
+func (js JSString) Emit(wr io.Writer) int {
+	return js.EmitCtx(context.Background(), wr)
+}
+
+func (js JSString) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return emitEscaped(ctx, js.Cnt, &JSEscWriter{Escape: wr})
+}
+
+func (js JSString) Prestart(ctx context.Context) { goxic.Prestart(ctx, js.Cnt) }
+
+func JSStringWrap(c goxic.Content) goxic.Content { return JSString{c} }
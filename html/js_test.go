@@ -0,0 +1,77 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func TestJSEscWriter(t *testing.T) {
+	in := "a'b\"c`d\\e\n</script><!--${x}\u2028ä\xff"
+	const expect = `a\u0027b\u0022c\` + "`" + `d\\e\n\u003C/script\u003E\u003C!--\u0024{x}\u2028ä\uFFFD`
+	var buf bytes.Buffer
+	ewr := JSEscWriter{Escape: &buf}
+	n, err := ewr.Write([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+	buf.Reset()
+	for _, b := range []byte(in) {
+		ewr.Write([]byte{b})
+	}
+	if buf.String() != expect {
+		t.Errorf("bytewise: expected '%s', got '%s'", expect, buf.String())
+	}
+}
+
+func TestJSString_incomplete(t *testing.T) {
+	var buf bytes.Buffer
+	n := JSString{goxic.Print{V: "caf\xe2\x82"}}.Emit(&buf)
+	if s := buf.String(); s != `caf\uFFFD\uFFFD` {
+		t.Errorf("unexpected output '%s'", s)
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n := JSON{map[string]string{"x": "</script><!--\u2028&"}}.Emit(&buf)
+	const expect = `{"x":"\u003c/script\u003e\u003c!--\u2028\u0026"}`
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+	_, err := goxic.CatchEmit(
+		goxic.NewTemplate("").Ph("x").NewInitBounT(JSON{func() {}}, nil),
+		&buf)
+	if err == nil {
+		t.Error("no error for unsupported type")
+	}
+}
+
+func ExampleJSON() {
+	tmpl := goxic.NewTemplate("island").
+		AddStr(`<script>var user = `).Ph("user").
+		AddStr(`; greet('`).PhWrap("name", JSStringWrap).
+		AddStr(`');</script>`)
+	bt := tmpl.NewBounT(nil)
+	bt.BindName("user", JSON{struct{ Name string }{"</script>"}})
+	bt.BindPName("name", "Tom's")
+	bt.Emit(os.Stdout)
+	// Output:
+	// <script>var user = {"Name":"\u003c/script\u003e"}; greet('Tom\u0027s');</script>
+}
@@ -0,0 +1,328 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+	xhtml "golang.org/x/net/html"
+)
+
+func init() {
+	goxic.RegisterWrapper("sanitize", SanitizeWrap)
+}
+
+// Policy configures which elements and attributes Sanitized lets pass.
+// Element and attribute names are lower case.
+type Policy struct {
+	// Elements maps the allowed elements to their allowed attributes.
+	Elements map[string][]string
+	// GlobalAttrs are allowed for all allowed elements.
+	GlobalAttrs []string
+	// URLAttrs are attributes with URL values. They are dropped if the URL
+	// has a scheme that is not in Schemes – DefaultSchemes if nil.
+	URLAttrs []string
+	Schemes  []string
+	// DropContent are elements that are removed together with their
+	// content, e.g. script. Other elements that are not allowed are
+	// removed but their content is kept.
+	DropContent []string
+	// NoFollow adds rel="nofollow" to all a elements with href.
+	NoFollow bool
+}
+
+// UGCPolicy creates a policy for user generated content like comments.
+// It allows basic text formatting, lists, quotes and links with
+// rel="nofollow".
+func UGCPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"a": {"href"}, "abbr": nil, "b": nil, "blockquote": {"cite"},
+			"br": nil, "code": nil, "dd": nil, "del": nil, "dl": nil,
+			"dt": nil, "em": nil, "hr": nil, "i": nil, "ins": nil,
+			"kbd": nil, "li": nil, "mark": nil, "ol": {"start"}, "p": nil,
+			"pre": nil, "q": {"cite"}, "s": nil, "small": nil, "span": nil,
+			"strong": nil, "sub": nil, "sup": nil, "u": nil, "ul": nil,
+		},
+		GlobalAttrs: []string{"title", "lang", "dir"},
+		URLAttrs:    []string{"href", "src", "cite"},
+		DropContent: []string{
+			"script", "style", "iframe", "object", "embed", "template",
+			"noscript", "noembed", "noframes", "xmp", "plaintext", "title",
+			"textarea", "select", "svg", "math",
+		},
+		NoFollow: true,
+	}
+}
+
+// DefaultPolicy is used by Sanitized without Policy.
+var DefaultPolicy = UGCPolicy()
+
+func (p *Policy) attrAllowed(elem, attr string) bool {
+	return hasString(p.Elements[elem], attr) || hasString(p.GlobalAttrs, attr)
+}
+
+func hasString(strs []string, s string) bool {
+	for _, e := range strs {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitized emits the untrusted HTML fragment from Cnt with only the
+// elements and attributes allowed by Policy. Text is escaped, comments
+// and doctypes are dropped and elements left open are closed. The output
+// is safe HTML, see SafeHTML. Cnt is tokenized while it is emitted, i.e.
+// only the current token is buffered.
+type Sanitized struct {
+	Cnt    goxic.Content
+	Policy *Policy
+}
+
+func (Sanitized) SafeHTML() {}
+
+func (s Sanitized) Prestart(ctx context.Context) { goxic.Prestart(ctx, s.Cnt) }
+
+func SanitizeWrap(c goxic.Content) goxic.Content { return Sanitized{Cnt: c} }
+
+// Sanitize sanitizes the HTML fragment frag with DefaultPolicy.
+func Sanitize(frag string) string {
+	var buf bytes.Buffer
+	Sanitized{Cnt: goxic.Data(frag)}.Emit(&buf)
+	return buf.String()
+}
+
+type sanWriter struct {
+	wr io.Writer
+	n  int
+}
+
+func (sw *sanWriter) Write(p []byte) (int, error) {
+	n, err := sw.wr.Write(p)
+	sw.n += n
+	if err != nil {
+		panic(goxic.EmitError{Count: sw.n, Err: err})
+	}
+	return n, nil
+}
+
+func (sw *sanWriter) str(s string) { sw.Write([]byte(s)) }
+
+func (sw *sanWriter) esc(s string) {
+	ewr := EscWriter{Escape: sw, ReplaceInvalid: true}
+	ewr.Write([]byte(s))
+	ewr.Close()
+}
+
+// sanPipe passes the content to the tokenizer that runs in its own
+// goroutine. Flushes are passed in order with the content, so that the
+// tokenizer flushes after it has emitted all complete tokens.
+type sanPipe struct {
+	in      chan sanMsg
+	flushed chan error
+	stop    chan struct{}
+	abort   bool
+	// used by the tokenizer
+	out *sanWriter
+	buf []byte
+}
+
+type sanMsg struct {
+	data  []byte
+	flush bool
+}
+
+var errSanitizeAbort = errors.New("goxic: sanitizing aborted")
+
+func (sp *sanPipe) Write(p []byte) (int, error) {
+	select {
+	case sp.in <- sanMsg{data: append([]byte(nil), p...)}:
+		return len(p), nil
+	case <-sp.stop:
+		return 0, errSanitizeAbort
+	}
+}
+
+// Flush flushes the output of all complete tokens. An incomplete token is
+// kept until more content is written.
+func (sp *sanPipe) Flush() error {
+	select {
+	case sp.in <- sanMsg{flush: true}:
+	case <-sp.stop:
+		return errSanitizeAbort
+	}
+	select {
+	case err := <-sp.flushed:
+		return err
+	case <-sp.stop:
+		return errSanitizeAbort
+	}
+}
+
+func (sp *sanPipe) Read(p []byte) (int, error) {
+	for len(sp.buf) == 0 {
+		msg, ok := <-sp.in
+		switch {
+		case !ok && sp.abort:
+			return 0, errSanitizeAbort
+		case !ok:
+			return 0, io.EOF
+		case msg.flush:
+			sp.flushed <- goxic.Flush(sp.out.wr)
+		default:
+			sp.buf = msg.data
+		}
+	}
+	n := copy(p, sp.buf)
+	sp.buf = sp.buf[n:]
+	return n, nil
+}
+
+func (s Sanitized) Emit(wr io.Writer) int {
+	return s.EmitCtx(context.Background(), wr)
+}
+
+func (s Sanitized) EmitCtx(ctx context.Context, wr io.Writer) int {
+	pol := s.Policy
+	if pol == nil {
+		pol = DefaultPolicy
+	}
+	sp := &sanPipe{
+		in:      make(chan sanMsg),
+		flushed: make(chan error),
+		stop:    make(chan struct{}),
+		out:     &sanWriter{wr: wr},
+	}
+	var srek interface{}
+	go func() {
+		defer close(sp.stop)
+		defer func() { srek = recover() }()
+		pol.sanitize(sp.out, sp)
+	}()
+	rek := func() (rek interface{}) {
+		defer func() { rek = recover() }()
+		goxic.EmitCtx(ctx, s.Cnt, sp)
+		return nil
+	}()
+	sp.abort = rek != nil
+	close(sp.in)
+	<-sp.stop
+	if ee, ok := srek.(goxic.EmitError); srek != nil && !(ok && ee.Err == errSanitizeAbort) {
+		panic(srek)
+	}
+	if rek != nil {
+		panic(rek)
+	}
+	return sp.out.n
+}
+
+func (p *Policy) sanitize(out *sanWriter, src io.Reader) {
+	var (
+		open  []string
+		skip  string
+		depth int
+	)
+	z := xhtml.NewTokenizer(src)
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				panic(goxic.EmitError{Count: out.n, Err: err})
+			}
+			break
+		}
+		tok := z.Token()
+		if skip != "" {
+			switch {
+			case tt == xhtml.StartTagToken && tok.Data == skip:
+				depth++
+			case tt == xhtml.EndTagToken && tok.Data == skip:
+				if depth--; depth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+		switch tt {
+		case xhtml.TextToken:
+			out.esc(tok.Data)
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if _, ok := p.Elements[tok.Data]; !ok {
+				if tt == xhtml.StartTagToken && hasString(p.DropContent, tok.Data) {
+					skip, depth = tok.Data, 1
+				}
+				continue
+			}
+			p.startTag(out, &tok)
+			if !IsVoid(tok.Data) {
+				if tt == xhtml.SelfClosingTagToken {
+					out.str("</" + tok.Data + ">")
+				} else {
+					open = append(open, tok.Data)
+				}
+			}
+		case xhtml.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						out.str("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.str("</" + open[i] + ">")
+	}
+}
+
+func (p *Policy) startTag(out *sanWriter, tok *xhtml.Token) {
+	out.str("<" + tok.Data)
+	var seen []string
+	rel := ""
+	href := false
+	for _, a := range tok.Attr {
+		key := a.Key
+		if a.Namespace != "" || hasString(seen, key) || !p.attrAllowed(tok.Data, key) {
+			continue
+		}
+		if hasString(p.URLAttrs, key) {
+			schemes := p.Schemes
+			if schemes == nil {
+				schemes = DefaultSchemes
+			}
+			if !allowedScheme([]byte(a.Val), schemes) {
+				continue
+			}
+			href = href || key == "href"
+		}
+		seen = append(seen, key)
+		if key == "rel" {
+			rel = a.Val
+			continue
+		}
+		out.str(" " + key + `="`)
+		out.esc(a.Val)
+		out.str(`"`)
+	}
+	if p.NoFollow && tok.Data == "a" && href {
+		if !hasString(strings.Fields(rel), "nofollow") {
+			rel = strings.TrimSpace(rel + " nofollow")
+		}
+	}
+	if rel != "" {
+		out.str(` rel="`)
+		out.esc(rel)
+		out.str(`"`)
+	}
+	out.str(">")
+}
@@ -0,0 +1,116 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+// bytewise emits Data one byte at a time.
+type bytewise goxic.Data
+
+func (b bytewise) Emit(wr io.Writer) (n int) {
+	for i := range b {
+		c, _ := wr.Write(b[i : i+1])
+		n += c
+	}
+	return n
+}
+
+var sanitizeTests = map[string]string{
+	"plain & simple":                                           "plain &amp; simple",
+	"<b>bold</b> <blink>on</blink>":                            "<b>bold</b> on",
+	"<p>open <em>nested":                                       "<p>open <em>nested</em></p>",
+	"<em>a<b>b</em>c</b>":                                      "<em>a<b>b</b></em>c",
+	"x<script>alert(1)</script>y":                              "xy",
+	"<style>p{}</style><p>ok</p>":                              "<p>ok</p>",
+	"<svg><svg></svg><p>in</p></svg>after":                     "after",
+	"<!-- comment -->text":                                     "text",
+	`<p onclick="evil()" title="t&quot;">x</p>`:                `<p title="t&quot;">x</p>`,
+	`<a href="javascript:alert(1)">x</a>`:                      `<a>x</a>`,
+	`<a href="/p" rel="author">x</a>`:                          `<a href="/p" rel="nofollow">x</a>`,
+	`<a HREF="https://x.org/?a=1&amp;b=2">`:                    `<a href="https://x.org/?a=1&amp;b=2" rel="nofollow"></a>`,
+	"line<br/>break<br>":                                       "line<br>break<br>",
+	"<span/>x":                                                 "<span></span>x",
+	"</p>stray":                                                "stray",
+	"<img src=x onerror=alert(1)>":                             "",
+	"&lt;b&gt; &amp &copy; &#60; &#x3C; &bogus; & a":           "&lt;b&gt; &amp; © &lt; &lt; &amp;bogus; &amp; a",
+	"a < b <3 <>":                                              "a &lt; b &lt;3 &lt;&gt;",
+	`<a href="javascript&colon;alert(1)">x</a>`:                `<a>x</a>`,
+	"<script>a</scriptx>b</script>c":                           "c",
+	"<script>if (a<b) x='</p>'</SCRIPT >c":                     "c",
+	"<b title='a\"b' title=2 lang=en>x</b >":                   `<b title="a&quot;b" lang="en">x</b>`,
+	"<!--> a <!---> b <!-- c --!> d <!-x> e <?pi> f":           " a  b  d  e  f",
+	"x</>y</ p>z":                                              "xyz",
+	"<em>open <b":                                              "<em>open </em>",
+	"tail &amp":                                                "tail &amp;",
+	"<a href=\" java\tscript:alert(1)\">x</a>":                 "<a>x</a>",
+	"<a href=\"JaVaScRiPt:alert(1)\">x</a>":                    "<a>x</a>",
+	"<a href=\"&#106;avascript:alert(1)\">x</a>":               "<a>x</a>",
+	"<a/href=\"javascript:x\"/onclick=y>x</a>":                 "<a>x</a>",
+	"<b\x00 onclick=x>y</b>":                                   "y",
+	"<scr<script>ipt>alert(1)</script>":                        "ipt&gt;alert(1)",
+	"<noscript><p title=\"</noscript><img src=x onerror=y>\">": "&quot;&gt;",
+	"<b <i>x</i>":                                              "<b>x</b>",
+	"<p title=\"a\nb\" lang=`x`>z":                             "<p title=\"a\nb\" lang=\"`x`\">z</p>",
+}
+
+func TestSanitized(t *testing.T) {
+	for in, expect := range sanitizeTests {
+		for _, cnt := range []goxic.Content{goxic.Data(in), bytewise(in)} {
+			var buf bytes.Buffer
+			n := Sanitized{Cnt: cnt}.Emit(&buf)
+			if buf.String() != expect {
+				t.Errorf("%q %T: expected '%s', got '%s'", in, cnt, expect, buf.String())
+			}
+			if n != buf.Len() {
+				t.Errorf("%q: counted %d bytes, wrote %d", in, n, buf.Len())
+			}
+		}
+	}
+}
+
+func TestSanitized_rcdata(t *testing.T) {
+	pol := &Policy{Elements: map[string][]string{"textarea": nil}}
+	var buf bytes.Buffer
+	Sanitized{
+		Cnt:    bytewise("<textarea><b>&amp;</b>&lt;</textarea>x"),
+		Policy: pol,
+	}.Emit(&buf)
+	const expect = "<textarea>&lt;b&gt;&amp;&lt;/b&gt;&lt;</textarea>x"
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+}
+
+func TestSanitized_policy(t *testing.T) {
+	pol := &Policy{
+		Elements: map[string][]string{"img": {"src", "alt"}, "a": {"href", "rel"}},
+		URLAttrs: []string{"src", "href"},
+		Schemes:  []string{"https"},
+	}
+	var buf bytes.Buffer
+	Sanitized{
+		Cnt:    goxic.Data(`<img src="https://x.org/a.png" alt="A"><img src="http://x.org/b.png"><a href="/" rel="me">x</a>`),
+		Policy: pol,
+	}.Emit(&buf)
+	const expect = `<img src="https://x.org/a.png" alt="A"><img><a href="/" rel="me">x</a>`
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+}
+
+func ExampleSanitized() {
+	tmpl := goxic.NewTemplate("comment").AddStr("<div>").
+		PhWrap("text", SanitizeWrap).AddStr("</div>")
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("text", `<p>Nice <a href="https://example.com" onclick="steal()">link</a>!<script>steal()</script>`)
+	bt.Emit(os.Stdout)
+	// Output:
+	// <div><p>Nice <a href="https://example.com" rel="nofollow">link</a>!</p></div>
+}
@@ -0,0 +1,50 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"context"
+	"io"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+// SafeHTML is implemented by content that emits HTML that is safe to be
+// embedded into HTML documents without escaping.
+type SafeHTML interface {
+	goxic.Content
+	SafeHTML()
+}
+
+// Trusted marks Cnt as safe HTML. Only use it for content that cannot be
+// controlled by untrusted parties.
+type Trusted struct {
+	Cnt goxic.Content
+}
+
+func (t Trusted) Emit(wr io.Writer) int { return t.Cnt.Emit(wr) }
+
+func (t Trusted) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return goxic.EmitCtx(ctx, t.Cnt, wr)
+}
+
+func (t Trusted) Prestart(ctx context.Context) { goxic.Prestart(ctx, t.Cnt) }
+
+func (Trusted) SafeHTML() {}
+
+// TrustedString marks the string s as safe HTML.
+func TrustedString(s string) Trusted { return Trusted{goxic.Data(s)} }
+
+// IsTrusted reports whether c is safe HTML, i.e. c implements SafeHTML or
+// c is a bound template of a template with media type MediaType. Note
+// that a bound HTML template is trusted no matter how its placeholders
+// were bound.
+func IsTrusted(c goxic.Content) bool {
+	switch c := c.(type) {
+	case SafeHTML:
+		return true
+	case *goxic.BounT:
+		return c.Template().MediaType == MediaType
+	}
+	return false
+}
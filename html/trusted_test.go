@@ -0,0 +1,88 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func ExampleTrusted() {
+	ts := make(map[string]*goxic.Template)
+	err := NewParser().Parse(strings.NewReader(
+		"<div>`content|html`</div>\n"+
+			"<!-- >>> item >>> -->\n"+
+			"<li>`text|html`</li>\n"+
+			"<!-- <<< item <<< -->\n"),
+		"page",
+		ts)
+	if err != nil {
+		panic(err)
+	}
+	item := ts["item"].NewBounT(nil)
+	item.BindPName("text", "<b>")
+	page := ts[""].NewBounT(nil)
+	for _, c := range []goxic.Content{
+		item,
+		goxic.Print{V: "<i>"},
+		TrustedString("<i>"),
+		goxic.NewTemplate("plain").AddStr("<p>").NewBounT(nil),
+	} {
+		page.BindName("content", c)
+		page.Emit(os.Stdout)
+	}
+	// Output:
+	// <div><li>&lt;b&gt;</li></div>
+	// <div>&lt;i&gt;</div>
+	// <div><i></div>
+	// <div>&lt;p&gt;</div>
+}
+
+func TestEscaper_trusted(t *testing.T) {
+	for _, test := range []struct {
+		cnt        goxic.Content
+		html, attr string
+	}{
+		{NewElem("b", goxic.Data("x")), "<b>x</b>", "&lt;b&gt;x&lt;/b&gt;"},
+		{(&URL{Path: []string{"a b"}}).Param("x", "1&2"),
+			"a%20b?x=1%262", "a%20b?x&#61;1%262"},
+		{JSON{"<"}, `&quot;\u003c&quot;`, `&quot;\u003c&quot;`},
+		{goxic.Data("<i>"), "&lt;i&gt;", "&lt;i&gt;"},
+	} {
+		var buf bytes.Buffer
+		Escaper{test.cnt}.Emit(&buf)
+		if s := buf.String(); s != test.html {
+			t.Errorf("html: expected '%s', got '%s'", test.html, s)
+		}
+		buf.Reset()
+		ModeEscaper{Cnt: test.cnt}.Emit(&buf)
+		if s := buf.String(); s != test.html {
+			t.Errorf("mode default: expected '%s', got '%s'", test.html, s)
+		}
+		buf.Reset()
+		ModeEscaper{Cnt: test.cnt, Mode: EscAttr}.Emit(&buf)
+		if s := buf.String(); s != test.attr {
+			t.Errorf("mode attr: expected '%s', got '%s'", test.attr, s)
+		}
+	}
+}
+
+func TestEscaper_jsonAttr(t *testing.T) {
+	ts := make(map[string]*goxic.Template)
+	err := NewParser().Parse(strings.NewReader("<div data-x=\"`x|html`\">"), "attr", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindName("x", JSON{V: "x onmouseover=alert(1) y"})
+	var buf bytes.Buffer
+	bt.Emit(&buf)
+	const expect = `<div data-x="&quot;x onmouseover=alert(1) y&quot;">`
+	if s := buf.String(); s != expect {
+		t.Errorf("expected '%s', got '%s'", expect, s)
+	}
+}
@@ -0,0 +1,281 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func init() {
+	goxic.RegisterWrapper("url", SafeURLWrap)
+	goxic.RegisterWrapper("urlpath", URLPathWrap)
+	goxic.RegisterWrapper("urlquery", URLQueryWrap)
+}
+
+// URLEscWriter percent-encodes everything written to it except the
+// unreserved characters of RFC 3986. With Query set, space is written as
+// '+' as in query components of URLs. The output is also safe to use in
+// HTML text and attribute values.
+type URLEscWriter struct {
+	Escape io.Writer
+	Query  bool
+}
+
+const upperHex = "0123456789ABCDEF"
+
+func urlUnreserved(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func (uw *URLEscWriter) Write(p []byte) (n int, err error) {
+	var buf [64]byte
+	out := buf[:0]
+	for _, c := range p {
+		switch {
+		case urlUnreserved(c):
+			out = append(out, c)
+		case c == ' ' && uw.Query:
+			out = append(out, '+')
+		default:
+			out = append(out, '%', upperHex[c>>4], upperHex[c&0xf])
+		}
+		if len(out) > len(buf)-3 {
+			i, err := uw.Escape.Write(out)
+			if n += i; err != nil {
+				return n, err
+			}
+			out = out[:0]
+		}
+	}
+	if len(out) > 0 {
+		i, err := uw.Escape.Write(out)
+		n += i
+		return n, err
+	}
+	return n, nil
+}
+
+// Flush flushes Escape, see goxic.Flush.
+func (uw *URLEscWriter) Flush() error { return goxic.Flush(uw.Escape) }
+
+// URLPath emits Cnt escaped as a single path segment of a URL.
+type URLPath struct {
+	Cnt goxic.Content
+}
+
+func (up URLPath) Emit(wr io.Writer) int {
+	return up.EmitCtx(context.Background(), wr)
+}
+
+func (up URLPath) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return goxic.EmitCtx(ctx, up.Cnt, &URLEscWriter{Escape: wr})
+}
+
+func (up URLPath) Prestart(ctx context.Context) { goxic.Prestart(ctx, up.Cnt) }
+
+func URLPathWrap(c goxic.Content) goxic.Content { return URLPath{c} }
+
+// URLQuery emits Cnt escaped as a query component of a URL, i.e. a
+// parameter name or value.
+type URLQuery struct {
+	Cnt goxic.Content
+}
+
+func (uq URLQuery) Emit(wr io.Writer) int {
+	return uq.EmitCtx(context.Background(), wr)
+}
+
+func (uq URLQuery) EmitCtx(ctx context.Context, wr io.Writer) int {
+	return goxic.EmitCtx(ctx, uq.Cnt, &URLEscWriter{Escape: wr, Query: true})
+}
+
+func (uq URLQuery) Prestart(ctx context.Context) { goxic.Prestart(ctx, uq.Cnt) }
+
+func URLQueryWrap(c goxic.Content) goxic.Content { return URLQuery{c} }
+
+// DefaultSchemes are the URL schemes SafeURL allows if it has no
+// explicit Schemes.
+var DefaultSchemes = []string{"http", "https", "mailto"}
+
+// UnsafeURL replaces URLs with a scheme that is not allowed.
+const UnsafeURL = "about:invalid#goxic"
+
+// SafeURL emits the URL from Cnt if it has no scheme, i.e. it is a
+// relative URL, or if its scheme is one of Schemes. Otherwise it emits
+// UnsafeURL. This neutralises e.g. javascript: and data: URLs. The URL is
+// HTML escaped to be used in attribute values.
+type SafeURL struct {
+	Cnt     goxic.Content
+	Schemes []string
+}
+
+func (su SafeURL) Emit(wr io.Writer) int {
+	return su.EmitCtx(context.Background(), wr)
+}
+
+func (su SafeURL) EmitCtx(ctx context.Context, wr io.Writer) int {
+	var buf bytes.Buffer
+	goxic.EmitCtx(ctx, su.Cnt, &buf)
+	schemes := su.Schemes
+	if schemes == nil {
+		schemes = DefaultSchemes
+	}
+	url := buf.Bytes()
+	if !allowedScheme(url, schemes) {
+		url = []byte(UnsafeURL)
+	}
+	return emitEscaped(ctx, goxic.Data(url), &EscWriter{Escape: wr})
+}
+
+func (su SafeURL) Prestart(ctx context.Context) { goxic.Prestart(ctx, su.Cnt) }
+
+func SafeURLWrap(c goxic.Content) goxic.Content { return SafeURL{Cnt: c} }
+
+// URLScheme returns the lower case scheme of url the way browsers
+// recognise it, i.e. ignoring leading control characters and spaces and
+// all tabs and newlines. It returns "" for relative URLs.
+func URLScheme(url []byte) string {
+	url = bytes.TrimLeft(url, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\t\n\v\f\r"+
+		"\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f ")
+	var scheme []byte
+	for _, c := range url {
+		switch {
+		case c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == ':':
+			return strings.ToLower(string(scheme))
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case len(scheme) > 0 && (('0' <= c && c <= '9') || c == '+' || c == '-' || c == '.'):
+		default:
+			return ""
+		}
+		scheme = append(scheme, c)
+	}
+	return ""
+}
+
+func allowedScheme(url []byte, schemes []string) bool {
+	scheme := URLScheme(url)
+	if scheme == "" {
+		return !bytes.ContainsRune(url, ':') || relativeColon(url)
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeColon reports whether the first ':' in url comes after a '/',
+// '?' or '#', i.e. the URL is relative.
+func relativeColon(url []byte) bool {
+	colon := bytes.IndexByte(url, ':')
+	sep := bytes.IndexAny(url, "/?#")
+	return sep >= 0 && sep < colon
+}
+
+// QueryParam is a parameter of a URL query.
+type QueryParam struct {
+	Name, Value string
+}
+
+// URL is content that assembles a URL from its parts. Path segments and
+// query parameters are escaped. The URL is HTML escaped to be used in
+// attribute values. Without Scheme and Host the URL is relative. A
+// relative URL with Abs set starts with '/'.
+type URL struct {
+	Scheme   string
+	Host     string
+	Abs      bool
+	Path     []string
+	Query    []QueryParam
+	Fragment string
+}
+
+// Seg appends path segments to u.
+func (u *URL) Seg(segs ...string) *URL {
+	u.Path = append(u.Path, segs...)
+	return u
+}
+
+// Param appends the query parameter name=value to u.
+func (u *URL) Param(name, value string) *URL {
+	u.Query = append(u.Query, QueryParam{name, value})
+	return u
+}
+
+func (*URL) SafeHTML() {}
+
+func (u *URL) Emit(wr io.Writer) int {
+	if err := u.check(); err != nil {
+		panic(goxic.EmitError{Err: err})
+	}
+	var buf bytes.Buffer
+	if u.Scheme != "" {
+		buf.WriteString(strings.ToLower(u.Scheme))
+		buf.WriteByte(':')
+	}
+	if u.Host != "" {
+		buf.WriteString("//")
+		buf.WriteString(u.Host)
+	}
+	pesc := URLEscWriter{Escape: &buf}
+	for i, seg := range u.Path {
+		if i > 0 || u.Host != "" || u.Abs {
+			buf.WriteByte('/')
+		}
+		pesc.Write([]byte(seg))
+	}
+	if len(u.Path) == 0 && u.Abs {
+		buf.WriteByte('/')
+	}
+	qesc := URLEscWriter{Escape: &buf, Query: true}
+	for i, p := range u.Query {
+		if i == 0 {
+			buf.WriteByte('?')
+		} else {
+			buf.WriteByte('&')
+		}
+		qesc.Write([]byte(p.Name))
+		buf.WriteByte('=')
+		qesc.Write([]byte(p.Value))
+	}
+	if u.Fragment != "" {
+		buf.WriteByte('#')
+		pesc.Write([]byte(u.Fragment))
+	}
+	return emitEscaped(context.Background(), goxic.Data(buf.Bytes()), &EscWriter{Escape: wr})
+}
+
+func (u *URL) check() error {
+	for i := 0; i < len(u.Scheme); i++ {
+		c := u.Scheme[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && (('0' <= c && c <= '9') || c == '+' || c == '-' || c == '.'):
+		default:
+			return fmt.Errorf("invalid URL scheme '%s'", u.Scheme)
+		}
+	}
+	for i := 0; i < len(u.Host); i++ {
+		c := u.Host[i]
+		switch {
+		case urlUnreserved(c):
+		case strings.IndexByte(":[]%", c) >= 0:
+		default:
+			return fmt.Errorf("invalid URL host '%s'", u.Host)
+		}
+	}
+	return nil
+}
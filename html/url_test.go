@@ -0,0 +1,95 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package html
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+func TestURLEscWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ewr := URLEscWriter{Escape: &buf}
+	in := strings.Repeat("a b/ä&", 20)
+	n, err := ewr.Write([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := strings.Repeat("a%20b%2F%C3%A4%26", 20)
+	if buf.String() != expect {
+		t.Errorf("expected '%s', got '%s'", expect, buf.String())
+	}
+	if n != buf.Len() {
+		t.Errorf("counted %d bytes, wrote %d", n, buf.Len())
+	}
+	buf.Reset()
+	ewr.Query = true
+	ewr.Write([]byte("a b+c"))
+	if buf.String() != "a+b%2Bc" {
+		t.Errorf("wrong query escape '%s'", buf.String())
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for url, expect := range map[string]string{
+		"https://example.com/?a=1&b=2": "https://example.com/?a=1&amp;b=2",
+		"/relative/path:x":             "/relative/path:x",
+		"page.html#top":                "page.html#top",
+		"MAILTO:joe@example.com":       "MAILTO:joe@example.com",
+		"javascript:alert(1)":          UnsafeURL,
+		" \x01JavaScript:alert(1)":     UnsafeURL,
+		"java\tscript:alert(1)":        UnsafeURL,
+		"data:text/html,<script>":      UnsafeURL,
+		"java script:alert(1)":         UnsafeURL,
+	} {
+		var buf bytes.Buffer
+		n := SafeURL{Cnt: goxic.Print{V: url}}.Emit(&buf)
+		if buf.String() != expect {
+			t.Errorf("%q: expected '%s', got '%s'", url, expect, buf.String())
+		}
+		if n != buf.Len() {
+			t.Errorf("%q: counted %d bytes, wrote %d", url, n, buf.Len())
+		}
+	}
+	var buf bytes.Buffer
+	SafeURL{Cnt: goxic.Print{V: "data:image/png;base64,AAAA"}, Schemes: []string{"data"}}.
+		Emit(&buf)
+	if buf.String() != "data:image/png;base64,AAAA" {
+		t.Errorf("allowed data URL was replaced: '%s'", buf.String())
+	}
+}
+
+func ExampleURL() {
+	u := &URL{Scheme: "https", Host: "example.com"}
+	u.Seg("docs", "a/b c").Param("q", "Tom & Jerry").Param("lang", "en")
+	u.Fragment = "top"
+	u.Emit(os.Stdout)
+	os.Stdout.WriteString("\n")
+	(&URL{Abs: true, Path: []string{"search"}}).Param("x", "1").Emit(os.Stdout)
+	// Output:
+	// https://example.com/docs/a%2Fb%20c?q=Tom+%26+Jerry&amp;lang=en#top
+	// /search?x=1
+}
+
+func ExampleSafeURLWrap() {
+	ts := make(map[string]*goxic.Template)
+	err := NewParser().Parse(
+		strings.NewReader(`<a href="`+"`link|url`"+`">`+
+			`<a href="/u/`+"`user|urlpath`?q=`query|urlquery`"+`">`),
+		"page",
+		ts)
+	if err != nil {
+		panic(err)
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("link", "javascript:alert('pwnd')")
+	bt.BindPName("user", "../admin")
+	bt.BindPName("query", "a&b")
+	bt.Emit(os.Stdout)
+	// Output:
+	// <a href="about:invalid#goxic"><a href="/u/..%2Fadmin?q=a%26b">
+}
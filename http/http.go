@@ -0,0 +1,225 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+
+// Package http renders bound templates as responses of net/http handlers.
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+)
+
+// DefaultMediaType is used as content type for templates without
+// MediaType.
+const DefaultMediaType = "text/plain"
+
+// Renderer renders bound templates into HTTP responses. The response is
+// completely rendered into a buffer before anything is sent. This allows
+// to answer with status 500 and no partial output when emitting fails,
+// to set Content-Length and to compute an ETag from the rendered bytes.
+type Renderer struct {
+	// Charset is added to the content type of text/* media types. Default
+	// is utf-8.
+	Charset string
+	// Gzip enables gzip compression for clients that accept it.
+	Gzip bool
+	// GzipMin is the minimal size of a response body to be compressed.
+	GzipMin int
+	// OnError is called with errors from emitting templates. The response
+	// is already sent when OnError is called.
+	OnError func(r *nethttp.Request, err error)
+}
+
+// DefaultRenderer is used by Render and Handler.
+var DefaultRenderer = &Renderer{Gzip: true, GzipMin: 512}
+
+// Render renders bt with DefaultRenderer.
+func Render(w nethttp.ResponseWriter, r *nethttp.Request, bt *goxic.BounT) error {
+	return DefaultRenderer.Render(w, r, bt)
+}
+
+// BounTFunc creates the bound template that answers request r.
+type BounTFunc func(r *nethttp.Request) (*goxic.BounT, error)
+
+// Handler returns a handler that renders the result of f with
+// DefaultRenderer.
+func Handler(f BounTFunc) nethttp.Handler {
+	return DefaultRenderer.Handler(f)
+}
+
+// Handler returns a handler that renders the result of f. If f returns
+// an error the handler answers with status 500.
+func (rd *Renderer) Handler(f BounTFunc) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		bt, err := f(r)
+		if err != nil {
+			rd.fail(w, r, err)
+			return
+		}
+		rd.Render(w, r, bt)
+	})
+}
+
+// Render renders bt into the response w with status 200. The content type
+// is taken from the MediaType of bt's template. If the request has an
+// If-None-Match header that matches the ETag of the rendered response, the
+// response has status 304 and no body. Responses to HEAD requests have no
+// body. Render returns the error from emitting bt, if any. In that case
+// the response has status 500. The template is emitted with the request's
+// context, see goxic.BounT.EmitCtx.
+func (rd *Renderer) Render(w nethttp.ResponseWriter, r *nethttp.Request, bt *goxic.BounT) error {
+	var body bytes.Buffer
+	if _, err := goxic.CatchEmitCtx(r.Context(), bt, &body); err != nil {
+		rd.fail(w, r, err)
+		return err
+	}
+	hdr := w.Header()
+	hdr.Set("Content-Type", rd.contentType(bt.Template().MediaType))
+	etag := ETag(body.Bytes())
+	if rd.Gzip {
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+	gz := rd.Gzip && body.Len() >= rd.GzipMin && acceptsGzip(r)
+	if gz {
+		etag = gzipETag(etag)
+	}
+	hdr.Set("ETag", etag)
+	if noneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(nethttp.StatusNotModified)
+		return nil
+	}
+	if gz {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		zw.Write(body.Bytes())
+		zw.Close()
+		body = zbuf
+		hdr.Set("Content-Encoding", "gzip")
+	}
+	hdr.Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(nethttp.StatusOK)
+	if r.Method != nethttp.MethodHead {
+		w.Write(body.Bytes())
+	}
+	return nil
+}
+
+// Stream emits bt directly into the response w with status 200. At each
+// flush point of the template, see goxic.Template.Flush, the output
+// emitted so far is sent to the client. Stream neither computes an ETag
+// nor compresses the response. If emitting fails before anything was
+// written the response has status 500, otherwise the response is
+// truncated. In both cases Stream returns the error.
+func (rd *Renderer) Stream(w nethttp.ResponseWriter, r *nethttp.Request, bt *goxic.BounT) error {
+	w.Header().Set("Content-Type", rd.contentType(bt.Template().MediaType))
+	if r.Method == nethttp.MethodHead {
+		w.WriteHeader(nethttp.StatusOK)
+		return nil
+	}
+	sw := streamWriter{w: w}
+	if _, err := goxic.CatchEmitCtx(r.Context(), bt, &sw); err != nil {
+		if !sw.wrote {
+			rd.fail(w, r, err)
+		} else if rd.OnError != nil {
+			rd.OnError(r, err)
+		}
+		return err
+	}
+	return nil
+}
+
+type streamWriter struct {
+	w     nethttp.ResponseWriter
+	wrote bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	sw.wrote = true
+	return sw.w.Write(p)
+}
+
+// Flush sends the output written so far. Before the first write there is
+// nothing to send, so Flush keeps the status open for an error response.
+func (sw *streamWriter) Flush() {
+	if !sw.wrote {
+		return
+	}
+	if f, ok := sw.w.(nethttp.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rd *Renderer) fail(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
+	nethttp.Error(w,
+		nethttp.StatusText(nethttp.StatusInternalServerError),
+		nethttp.StatusInternalServerError)
+	if rd.OnError != nil {
+		rd.OnError(r, err)
+	}
+}
+
+func (rd *Renderer) contentType(mediaType string) string {
+	if mediaType == "" {
+		mediaType = DefaultMediaType
+	}
+	mt, params, err := mime.ParseMediaType(mediaType)
+	if err != nil || !strings.HasPrefix(mt, "text/") || params["charset"] != "" {
+		return mediaType
+	}
+	cs := rd.Charset
+	if cs == "" {
+		cs = "utf-8"
+	}
+	return mediaType + "; charset=" + cs
+}
+
+// ETag computes the strong entity tag of the response body data.
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func gzipETag(etag string) string {
+	return etag[:len(etag)-1] + `-gzip"`
+}
+
+// noneMatch reports whether the If-None-Match header value inm matches
+// etag with weak comparison.
+func noneMatch(inm, etag string) bool {
+	for _, t := range strings.Split(inm, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(r *nethttp.Request) bool {
+	for _, ae := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(ae, ",") {
+			coding, params, _ := strings.Cut(enc, ";")
+			if strings.TrimSpace(coding) != "gzip" {
+				continue
+			}
+			q := strings.TrimSpace(params)
+			if !strings.HasPrefix(q, "q=") {
+				return true
+			}
+			v, err := strconv.ParseFloat(q[2:], 64)
+			return err == nil && v > 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic/html"
+)
+
+func pageBounT(t *testing.T, title string) *goxic.BounT {
+	ts := make(map[string]*goxic.Template)
+	err := html.NewParser().Parse(
+		strings.NewReader("<title>`title|html`</title>"),
+		"page",
+		ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("title", title)
+	return bt
+}
+
+func TestRender(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	if err := Render(rec, req, pageBounT(t, "Tom & Jerry")); err != nil {
+		t.Fatal(err)
+	}
+	res := rec.Result()
+	if res.StatusCode != nethttp.StatusOK {
+		t.Errorf("unexpected status %d", res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type '%s'", ct)
+	}
+	if s := rec.Body.String(); s != "<title>Tom &amp; Jerry</title>" {
+		t.Errorf("unexpected body '%s'", s)
+	}
+	if cl := res.Header.Get("Content-Length"); cl != "30" {
+		t.Errorf("unexpected content length '%s'", cl)
+	}
+	if res.Header.Get("ETag") == "" {
+		t.Error("missing ETag")
+	}
+}
+
+func TestRender_head(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("HEAD", "/", nil)
+	if err := Render(rec, req, pageBounT(t, "Tom & Jerry")); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != nethttp.StatusOK {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("unexpected body '%s'", rec.Body.String())
+	}
+	if cl := rec.Header().Get("Content-Length"); cl != "30" {
+		t.Errorf("unexpected content length '%s'", cl)
+	}
+}
+
+func TestRender_etag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Render(rec, httptest.NewRequest("GET", "/", nil), pageBounT(t, "foo"))
+	etag := rec.Header().Get("ETag")
+	for _, inm := range []string{etag, "W/" + etag, `"x", ` + etag, "*"} {
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("If-None-Match", inm)
+		Render(rec, req, pageBounT(t, "foo"))
+		if rec.Code != nethttp.StatusNotModified {
+			t.Errorf("If-None-Match %s: unexpected status %d", inm, rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: unexpected body '%s'", inm, rec.Body.String())
+		}
+	}
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	Render(rec, req, pageBounT(t, "bar"))
+	if rec.Code != nethttp.StatusOK {
+		t.Errorf("changed content: unexpected status %d", rec.Code)
+	}
+}
+
+func TestRender_error(t *testing.T) {
+	tmpl := goxic.NewTemplate("page").AddStr("<p>").Ph("missing").AddStr("</p>")
+	var logged error
+	rd := Renderer{OnError: func(_ *nethttp.Request, err error) { logged = err }}
+	rec := httptest.NewRecorder()
+	err := rd.Render(rec, httptest.NewRequest("GET", "/", nil), tmpl.NewBounT(nil))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if logged != err {
+		t.Errorf("OnError called with '%v'", logged)
+	}
+	if rec.Code != nethttp.StatusInternalServerError {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "<p>") {
+		t.Errorf("partial output '%s'", rec.Body.String())
+	}
+}
+
+func TestRender_canceled(t *testing.T) {
+	tmpl := goxic.NewTemplate("page").Ph("x")
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("x", "foo")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	if err := Render(rec, req, bt); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if rec.Code != nethttp.StatusInternalServerError {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestRender_gzip(t *testing.T) {
+	rd := Renderer{Gzip: true}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+	if err := rd.Render(rec, req, pageBounT(t, "Tom & Jerry")); err != nil {
+		t.Fatal(err)
+	}
+	if ce := rec.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("unexpected content encoding '%s'", ce)
+	}
+	if v := rec.Header().Get("Vary"); v != "Accept-Encoding" {
+		t.Errorf("unexpected Vary '%s'", v)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(body); s != "<title>Tom &amp; Jerry</title>" {
+		t.Errorf("unexpected body '%s'", s)
+	}
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	rec = httptest.NewRecorder()
+	rd.Render(rec, req, pageBounT(t, "Tom & Jerry"))
+	if ce := rec.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("unexpected content encoding '%s'", ce)
+	}
+}
+
+func TestStream(t *testing.T) {
+	ts := make(map[string]*goxic.Template)
+	err := html.NewParser().Parse(
+		strings.NewReader("<head>\n<!-- ~~~ flush ~~~ -->\n<body>`body|html`</body>"),
+		"page",
+		ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("body", "Tom & Jerry")
+	rec := httptest.NewRecorder()
+	if err := Render(rec, httptest.NewRequest("GET", "/", nil), bt); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Flushed {
+		t.Error("Render flushed")
+	}
+	rec = httptest.NewRecorder()
+	if err := DefaultRenderer.Stream(rec, httptest.NewRequest("GET", "/", nil), bt); err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Flushed {
+		t.Error("Stream did not flush")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type '%s'", ct)
+	}
+	if s := rec.Body.String(); s != "<head>\n<body>Tom &amp; Jerry</body>" {
+		t.Errorf("unexpected body '%s'", s)
+	}
+	rec = httptest.NewRecorder()
+	missing := goxic.NewTemplate("page").Ph("missing").NewBounT(nil)
+	if err := DefaultRenderer.Stream(rec, httptest.NewRequest("GET", "/", nil), missing); err == nil {
+		t.Error("expected error")
+	}
+	if rec.Code != nethttp.StatusInternalServerError {
+		t.Errorf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestStream_nested(t *testing.T) {
+	inner := goxic.NewTemplate("inner").AddStr("a&").Flush().AddStr("b")
+	outer := goxic.NewTemplate("page").Flush().AddStr("<p>").
+		PhWrap("x", html.EscWrap).AddStr("</p>")
+	bt := outer.NewBounT(nil)
+	bt.BindName("x", inner.NewBounT(nil))
+	rec := httptest.NewRecorder()
+	if err := DefaultRenderer.Stream(rec, httptest.NewRequest("GET", "/", nil), bt); err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Flushed {
+		t.Error("nested flush point was lost")
+	}
+	if s := rec.Body.String(); s != "<p>a&amp;b</p>" {
+		t.Errorf("unexpected body '%s'", s)
+	}
+	rec = httptest.NewRecorder()
+	bt.BindName("x", goxic.NewTemplate("missing").Ph("y").NewBounT(nil))
+	if err := DefaultRenderer.Stream(rec, httptest.NewRequest("GET", "/", nil), bt); err == nil {
+		t.Error("expected error")
+	}
+	if rec.Code != nethttp.StatusOK || rec.Body.String() != "<p>" {
+		t.Errorf("unexpected response %d '%s'", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	missing := goxic.NewTemplate("page").Flush().Ph("missing").NewBounT(nil)
+	if err := DefaultRenderer.Stream(rec, httptest.NewRequest("GET", "/", nil), missing); err == nil {
+		t.Error("expected error")
+	}
+	if rec.Code != nethttp.StatusInternalServerError {
+		t.Errorf("flush before output: unexpected status %d", rec.Code)
+	}
+}
+
+func ExampleHandler() {
+	tmpl := goxic.NewTemplate("hello").AddStr("Hello ").Ph("name").AddStr("!")
+	h := Handler(func(r *nethttp.Request) (*goxic.BounT, error) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			return nil, errors.New("no name")
+		}
+		bt := tmpl.NewBounT(nil)
+		bt.BindPName("name", name)
+		return bt, nil
+	})
+	for _, url := range []string{"/?name=World", "/"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		fmt.Println(rec.Code, rec.Header().Get("Content-Type"))
+		io.Copy(os.Stdout, rec.Body)
+		fmt.Println()
+	}
+	// Output:
+	// 200 text/plain; charset=utf-8
+	// Hello World!
+	// 500 text/plain; charset=utf-8
+	// Internal Server Error
+}
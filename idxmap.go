@@ -4,65 +4,141 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
+// Unmapped reports the mismatches between a template and an index map.
 type Unmapped struct {
-	T            *Template
+	T *Template
+	// Placeholders of T that are not mapped to any field of the index map
 	Placeholders []string
+	// Missing placeholders that are mandatory in the index map but do not
+	// exist in T
+	Missing []string
 }
 
 func (u *Unmapped) Error() string {
 	buf := bytes.NewBuffer(nil)
-	fmt.Fprintf(buf,
-		"unmapped placeholders in template '%s': %s",
-		u.T.Name,
-		strings.Join(u.Placeholders, ", "))
+	if len(u.Placeholders) > 0 {
+		fmt.Fprintf(buf,
+			"unmapped placeholders in template '%s': %s",
+			u.T.Name,
+			strings.Join(u.Placeholders, ", "))
+	}
+	if len(u.Missing) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("; ")
+		}
+		fmt.Fprintf(buf,
+			"missing placeholders in template '%s': %s",
+			u.T.Name,
+			strings.Join(u.Missing, ", "))
+	}
 	return buf.String()
 }
 
 func IdName(nm string) string { return nm }
 
+var tmplPtrType = reflect.TypeOf((*Template)(nil))
+
+// InitIndexMap sets the fields of the struct pointed to by imap to the
+// placeholder indices of tmpl. Fields of type []int are mapped to the
+// placeholder given by the field's goxic tag or, without tag, to the name
+// computed by mapNames from the field name. Embedded structs are mapped as if
+// their fields were fields of imap. Other struct fields that have a goxic tag
+// or embed *Template map placeholders of a sub-template that were prefixed
+// with the sub-template name and NameSep by BounT.Fixate. An embedded
+// *Template is set to tmpl. Unexported fields are skipped.
+//
+// InitIndexMap returns nil if all placeholders of tmpl are mapped and all
+// mandatory fields found their placeholder. Otherwise the mismatches are
+// reported in the returned Unmapped.
 func InitIndexMap(imap interface{}, tmpl *Template, mapNames func(string) string) *Unmapped {
 	imTy := reflect.TypeOf(imap).Elem()
-	im := reflect.ValueOf(imap).Elem()
-	mappedPhs := make(map[string]bool)
-	switch imTy.Kind() {
-	case reflect.Struct:
-		for fidx := 0; fidx < imTy.NumField(); fidx++ {
-			sfTy := imTy.Field(fidx)
-			if sfTy.Anonymous && sfTy.Type == reflect.TypeOf(tmpl) {
-				// TODO at most once!
-				imapVal := reflect.ValueOf(imap).Elem()
-				imapVal.Field(fidx).Set(reflect.ValueOf(tmpl))
-			} else if ph, opt, err := isPhIdxs(&sfTy, mapNames); len(ph) > 0 {
-				var idxs []int = tmpl.PhIdxs(string(ph))
-				if idxs != nil {
-					mappedPhs[ph] = true
-					sf := im.Field(fidx)
-					sf.Set(reflect.ValueOf(idxs))
-				} else if opt {
-					sf := im.Field(fidx)
-					sf.Set(reflect.ValueOf(emptyIndices))
-				} // TODO error on missing mandatory indexes
-			} else if err != nil {
-				panic("failed to index field: " + err.Error())
-			}
-		}
-	default:
+	if imTy.Kind() != reflect.Struct {
 		panic("cannto make index map in " + imTy.Kind().String())
 	}
-	if len(mappedPhs) != tmpl.PhNum() {
-		um := &Unmapped{T: tmpl}
+	m := imapper{
+		tmpl:      tmpl,
+		mapNames:  mapNames,
+		mappedPhs: make(map[string]bool),
+	}
+	m.mapStruct(reflect.ValueOf(imap).Elem(), "", new(bool))
+	um := &Unmapped{T: tmpl, Missing: m.missing}
+	if len(m.mappedPhs) != tmpl.PhNum() {
 		for _, p := range tmpl.Phs() {
-			if _, ok := mappedPhs[p]; !ok {
+			if _, ok := m.mappedPhs[p]; !ok {
 				um.Placeholders = append(um.Placeholders, p)
 			}
 		}
-		return um
-	} else {
+		sort.Strings(um.Placeholders)
+	}
+	if len(um.Placeholders) == 0 && len(um.Missing) == 0 {
 		return nil
 	}
+	return um
+}
+
+type imapper struct {
+	tmpl      *Template
+	mapNames  func(string) string
+	mappedPhs map[string]bool
+	missing   []string
+}
+
+// mapStruct maps the fields of im. Embedded structs share tmplSet with their
+// embedding struct, sub-template structs get their own.
+func (m *imapper) mapStruct(im reflect.Value, prefix string, tmplSet *bool) {
+	imTy := im.Type()
+	for fidx := 0; fidx < imTy.NumField(); fidx++ {
+		sfTy := imTy.Field(fidx)
+		sf := im.Field(fidx)
+		if sfTy.Anonymous && sfTy.Type == tmplPtrType {
+			if !sf.CanSet() {
+				continue
+			}
+			if *tmplSet {
+				panic("failed to index field: *Template embedded more than once")
+			}
+			sf.Set(reflect.ValueOf(m.tmpl))
+			*tmplSet = true
+			continue
+		}
+		if len(sfTy.PkgPath) > 0 && !sfTy.Anonymous {
+			continue
+		}
+		ph, opt, err := isPhIdxs(&sfTy, m.mapNames)
+		if err != nil {
+			panic("failed to index field: " + err.Error())
+		} else if ph == "-" {
+			continue
+		}
+		_, tagged := sfTy.Tag.Lookup("goxic")
+		switch {
+		case sfTy.Type.Kind() == reflect.Struct && sfTy.Anonymous:
+			m.mapStruct(sf, prefix, tmplSet)
+		case sfTy.Type.Kind() == reflect.Struct && len(ph) > 0:
+			if tagged || isIndexMap(sfTy.Type) {
+				m.mapStruct(sf, prefix+ph+string(NameSep), new(bool))
+			}
+		case len(ph) == 0 || !sf.CanSet():
+		case sfTy.Type == idxsType:
+			ph = prefix + ph
+			if idxs := m.tmpl.PhIdxs(ph); idxs != nil {
+				m.mappedPhs[ph] = true
+				sf.Set(reflect.ValueOf(idxs))
+			} else if opt {
+				sf.Set(reflect.ValueOf(emptyIndices))
+			} else {
+				m.missing = append(m.missing, ph)
+			}
+		case len(sfTy.Tag.Get("goxic")) > 0:
+			panic(fmt.Sprintf("failed to index field: %s has type %s",
+				sfTy.Name,
+				sfTy.Type))
+		}
+	}
 }
 
 func MustIndexMap(imap interface{}, t *Template, mapNames func(string) string) {
@@ -78,13 +154,17 @@ func MapAll(unmapped *Unmapped) {
 	}
 }
 
-var emptyIndices = []int{}
+var (
+	emptyIndices = []int{}
+	idxsType     = reflect.TypeOf(emptyIndices)
+)
 
 func isPhIdxs(f *reflect.StructField,
 	mapNames func(string) string) (ph string, opt bool, err error) {
-	// TODO skip unsettable fields
 	mode, ph, err := parseTag(f.Tag.Get("goxic"))
 	switch mode {
+	case tagIgnore:
+		return ph, false, nil
 	case tagMand:
 		opt = false
 	case tagOpt:
@@ -98,3 +178,138 @@ func isPhIdxs(f *reflect.StructField,
 	}
 	return ph, opt, nil
 }
+
+// UnmappedSet reports the mismatches found by InitIndexMaps.
+type UnmappedSet struct {
+	// Templates that are mandatory in the index maps but are missing from
+	// the template set
+	Templates []string
+	// Maps holds the mismatches of single index maps by template key
+	Maps map[string]*Unmapped
+}
+
+func (u *UnmappedSet) Error() string {
+	buf := bytes.NewBuffer(nil)
+	if len(u.Templates) > 0 {
+		fmt.Fprintf(buf, "missing templates: %s", strings.Join(u.Templates, ", "))
+	}
+	keys := make([]string, 0, len(u.Maps))
+	for k := range u.Maps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if buf.Len() > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(u.Maps[k].Error())
+	}
+	return buf.String()
+}
+
+// InitIndexMaps initializes a whole tree of index maps from a template set
+// as returned by Parser.Parse. Each field of the struct pointed to by imaps
+// that is a struct (or a pointer to a struct) with an embedded *Template is
+// initialized with InitIndexMap from the template with the key given by the
+// field's goxic tag, e.g. `goxic:"sub"`. Use `goxic:""` for the root
+// template. Without tag the key is computed by mapNames from the field name.
+// A missing template is reported unless the tag has the opt option, e.g.
+// `goxic:"sub opt"`. Struct fields without embedded *Template group index
+// maps of sub-templates, i.e. the key of such a field is prefixed to the keys
+// of its fields, separated by PathSep.
+//
+// InitIndexMaps returns nil if all templates are found and all index maps
+// match their templates.
+func InitIndexMaps(imaps interface{}, ts map[string]*Template, mapNames func(string) string) *UnmappedSet {
+	imTy := reflect.TypeOf(imaps).Elem()
+	if imTy.Kind() != reflect.Struct {
+		panic("cannto make index maps in " + imTy.Kind().String())
+	}
+	res := &UnmappedSet{Maps: make(map[string]*Unmapped)}
+	initIdxMapSet(reflect.ValueOf(imaps).Elem(), "", ts, mapNames, res)
+	if len(res.Templates) == 0 && len(res.Maps) == 0 {
+		return nil
+	}
+	return res
+}
+
+func MustIndexMaps(imaps interface{}, ts map[string]*Template, mapNames func(string) string) {
+	if unmapped := InitIndexMaps(imaps, ts, mapNames); unmapped != nil {
+		panic(unmapped)
+	}
+}
+
+func initIdxMapSet(
+	set reflect.Value,
+	prefix string,
+	ts map[string]*Template,
+	mapNames func(string) string,
+	res *UnmappedSet,
+) {
+	setTy := set.Type()
+	for fidx := 0; fidx < setTy.NumField(); fidx++ {
+		sfTy := setTy.Field(fidx)
+		sf := set.Field(fidx)
+		if len(sfTy.PkgPath) > 0 || !sf.CanSet() {
+			continue
+		}
+		var key string
+		var opt bool
+		if tag, ok := sfTy.Tag.Lookup("goxic"); !ok {
+			if mapNames == nil {
+				continue
+			}
+			key = mapNames(sfTy.Name)
+		} else if len(tag) > 0 {
+			mode, ph, err := parseTag(tag)
+			if err != nil {
+				panic("failed to index field: " + err.Error())
+			} else if mode == tagIgnore {
+				continue
+			}
+			key, opt = ph, mode == tagOpt
+		}
+		fTy := sfTy.Type
+		if fTy.Kind() == reflect.Ptr {
+			fTy = fTy.Elem()
+		}
+		if fTy.Kind() != reflect.Struct {
+			continue
+		}
+		if len(prefix) > 0 {
+			key = prefix + string(PathSep) + key
+		}
+		if !isIndexMap(fTy) {
+			if sf.Kind() == reflect.Ptr && sf.IsNil() {
+				sf.Set(reflect.New(fTy))
+			}
+			if sf.Kind() == reflect.Ptr {
+				sf = sf.Elem()
+			}
+			initIdxMapSet(sf, key, ts, mapNames, res)
+			continue
+		}
+		tmpl, ok := ts[key]
+		if !ok {
+			if !opt {
+				res.Templates = append(res.Templates, key)
+			}
+			continue
+		}
+		if sf.Kind() == reflect.Ptr {
+			if sf.IsNil() {
+				sf.Set(reflect.New(fTy))
+			}
+		} else {
+			sf = sf.Addr()
+		}
+		if um := InitIndexMap(sf.Interface(), tmpl, mapNames); um != nil {
+			res.Maps[key] = um
+		}
+	}
+}
+
+func isIndexMap(t reflect.Type) bool {
+	f, ok := t.FieldByName("Template")
+	return ok && f.Anonymous && f.Type == tmplPtrType
+}
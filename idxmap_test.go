@@ -2,8 +2,7 @@ package goxic
 
 import (
 	"testing"
-
-	"github.com/stvp/assert"
+	"time"
 )
 
 type IMap struct {
@@ -20,10 +19,166 @@ func TestIndexMap(t *testing.T) {
 	tmpl.Ph("quux")
 	var imap IMap
 	unmappend := InitIndexMap(&imap, tmpl, IdName)
-	assert.Equal(t, tmpl, imap.Template)
+	assertEqual(t, tmpl, imap.Template)
 	assertIndices(t, imap.Foo, 0)
 	assertIndices(t, imap.Bar, 1)
 	assertIndices(t, imap.Baz)
-	assert.Equal(t, 1, len(unmappend.Placeholders))
-	assert.Equal(t, "quux", unmappend.Placeholders[0])
+	assertEqual(t, 1, len(unmappend.Placeholders))
+	assertEqual(t, "quux", unmappend.Placeholders[0])
+}
+
+type GxtNest struct {
+	*Template
+	Id []int
+}
+
+type gxtFinal struct {
+	GxtNest
+	Name []int
+}
+
+func TestIndexMap_nest(t *testing.T) {
+	tmpl := NewTemplate(t.Name())
+	tmpl.Ph("Id")
+	tmpl.Ph("Name")
+	var imap gxtFinal
+	unmapped := InitIndexMap(&imap, tmpl, IdName)
+	if unmapped != nil {
+		t.Fatal(unmapped)
+	}
+	assertEqual(t, tmpl, imap.Template)
+	assertIndices(t, imap.Id, 0)
+	assertIndices(t, imap.Name, 1)
+}
+
+type imapSub struct {
+	Quux []int
+}
+
+type imapMissing struct {
+	Foo    []int
+	Bar    []int
+	Sub    imapSub `goxic:"sub"`
+	hidden []int
+	Ignore []int `goxic:"-"`
+	Text   string
+}
+
+func TestIndexMap_missing(t *testing.T) {
+	tmpl := NewTemplate(t.Name())
+	tmpl.Ph("Foo")
+	tmpl.Ph("sub:Quux")
+	tmpl.Ph("quux")
+	tmpl.Ph("hidden")
+	var imap imapMissing
+	unmapped := InitIndexMap(&imap, tmpl, IdName)
+	if unmapped == nil {
+		t.Fatal("expected unmapped placeholders")
+	}
+	assertIndices(t, imap.Foo, 0)
+	assertIndices(t, imap.Sub.Quux, 1)
+	assertEqual(t, []string{"hidden", "quux"}, unmapped.Placeholders)
+	assertEqual(t, []string{"Bar"}, unmapped.Missing)
+	assertEqual(t,
+		"unmapped placeholders in template 'TestIndexMap_missing': hidden, quux; "+
+			"missing placeholders in template 'TestIndexMap_missing': Bar",
+		unmapped.Error())
+}
+
+type imapTwice struct {
+	*Template
+	GxtNest
+}
+
+func TestIndexMap_templateTwice(t *testing.T) {
+	tmpl := NewTemplate(t.Name())
+	tmpl.Ph("Id")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for *Template embedded twice")
+		}
+	}()
+	var imap imapTwice
+	InitIndexMap(&imap, tmpl, IdName)
+}
+
+type plainData struct {
+	Vals []int
+}
+
+type imapPlain struct {
+	Foo  []int
+	When time.Time
+	Data plainData
+}
+
+func TestIndexMap_plainStruct(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).Ph("Foo")
+	var imap imapPlain
+	if unmapped := InitIndexMap(&imap, tmpl, IdName); unmapped != nil {
+		t.Fatal(unmapped)
+	}
+	assertIndices(t, imap.Foo, 0)
+}
+
+type imapNestedTmpl struct {
+	*Template
+	Foo []int
+	Sub GxtNest
+}
+
+func TestIndexMap_nestedTemplate(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).Ph("Foo").Ph("Sub:Id")
+	var imap imapNestedTmpl
+	if unmapped := InitIndexMap(&imap, tmpl, IdName); unmapped != nil {
+		t.Fatal(unmapped)
+	}
+	assertEqual(t, tmpl, imap.Template)
+	assertIndices(t, imap.Foo, 0)
+	assertIndices(t, imap.Sub.Id, 1)
+}
+
+type imapSetItem struct {
+	*Template
+	Name []int
+}
+
+type imapSet struct {
+	Root struct {
+		*Template
+		Title []int
+		Items []int
+	} `goxic:""`
+	Item   imapSetItem `goxic:"item"`
+	Footer *struct {
+		Links struct {
+			*Template
+			Href []int
+		} `goxic:"links"`
+	} `goxic:"footer"`
+	Aside imapSetItem `goxic:"aside opt"`
+	Extra imapSetItem `goxic:"extra"`
+}
+
+func TestInitIndexMaps(t *testing.T) {
+	ts := map[string]*Template{
+		"":             NewTemplate("doc").Ph("Title").Ph("Items"),
+		"item":         NewTemplate("doc/item").Ph("Name").Ph("Price"),
+		"footer/links": NewTemplate("doc/footer/links").Ph("Href"),
+	}
+	var imaps imapSet
+	unmapped := InitIndexMaps(&imaps, ts, IdName)
+	if unmapped == nil {
+		t.Fatal("expected unmapped templates")
+	}
+	assertEqual(t, ts[""], imaps.Root.Template)
+	assertIndices(t, imaps.Root.Items, 1)
+	assertIndices(t, imaps.Item.Name, 0)
+	assertIndices(t, imaps.Footer.Links.Href, 0)
+	assertEqual(t, []string{"extra"}, unmapped.Templates)
+	assertEqual(t, 1, len(unmapped.Maps))
+	assertEqual(t, []string{"Price"}, unmapped.Maps["item"].Placeholders)
+	assertEqual(t,
+		"missing templates: extra; unmapped placeholders in template 'doc/item': Price",
+		unmapped.Error())
 }
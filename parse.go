@@ -27,6 +27,26 @@ type Parser struct {
 	EndTBrkRgxGrp    int
 	Endl             string
 	PrepLine         func(string) string
+	// WrapSep separates the placeholder name from the names of wrappers,
+	// e.g. "title|trim|html" with WrapSep "|". The wrappers must be
+	// registered with RegisterWrapper. If WrapSep is empty, placeholder
+	// names are used verbatim.
+	WrapSep string
+	// AttrSep separates attributes in a placeholder spec, e.g.
+	// "title|html;default=Untitled;opt" with AttrSep ";". See ParsePhSpec for
+	// the complete syntax. If AttrSep is empty, placeholders have no
+	// attributes.
+	AttrSep string
+	// WS is the whitespace mode applied to the fixed fragments of parsed
+	// templates. WSByPath overrides WS for single templates by their path,
+	// i.e. by their key in the map filled by Parse.
+	WS       WSMode
+	WSByPath map[string]WSMode
+	// MediaType is set as media type of all parsed templates.
+	MediaType string
+	// FlushPoint matches lines that add a flush point to the template, see
+	// Template.Flush. Flush points are not recognised if FlushPoint is nil.
+	FlushPoint *regexp.Regexp
 }
 
 func NewParser(inlineStart, inlineEnd, lcomStart, lcomEnd string) *Parser {
@@ -62,6 +82,30 @@ func NewParser(inlineStart, inlineEnd, lcomStart, lcomEnd string) *Parser {
 	return res
 }
 
+// NewSpecParser creates a parser like NewParser that also understands
+// placeholder specs, i.e. wrappers and attributes separated by "|" and ";",
+// e.g. "title|html;opt", and flush points "<lcomStart> ~~~ flush ~~~
+// <lcomEnd>". Block placeholders may contain any non-blank characters to
+// allow for specs.
+func NewSpecParser(inlineStart, inlineEnd, lcomStart, lcomEnd string) *Parser {
+	res := NewParser(inlineStart, inlineEnd, lcomStart, lcomEnd)
+	res.BlockPh = regexp.MustCompile(
+		`^[ \t]*` +
+			lcomStart +
+			`(\\?) >>> (\S+) <<< (\\?)` +
+			lcomEnd +
+			`[ \t]*$`)
+	res.WrapSep = "|"
+	res.AttrSep = ";"
+	res.FlushPoint = regexp.MustCompile(
+		`^[ \t]*` +
+			lcomStart +
+			` ~~~ flush ~~~ ` +
+			lcomEnd +
+			`[ \t]*$`)
+	return res
+}
+
 func PrepTrimWS(line string) (trimmed string) {
 	return strings.Trim(line, " \t")
 }
@@ -161,6 +205,9 @@ func (err DuplicateTemplates) Error() string {
 	return buf.String()
 }
 
+// Parse reads templates from rd into the map into where the top level
+// template gets the name rootName. Unterminated inline placeholders and
+// unknown wrappers are reported as errors with the line number.
 func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template) error {
 	var dup DuplicateTemplates = make(map[string]*Template)
 	scn := bufio.NewScanner(rd)
@@ -168,7 +215,18 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 	pStr := ""
 	endl := ""
 	var curTmpl *Template = nil
+	parsed := make(map[string]bool)
+	store := func() {
+		if curTmpl != nil {
+			curTmpl.MediaType = p.MediaType
+		}
+		if storeTemplate(into, curTmpl, pStr, dup) && curTmpl != nil {
+			parsed[pStr] = true
+		}
+	}
+	lno := 0
 	for scn.Scan() {
+		lno++
 		line := scn.Text()
 		if match := p.StartSubTemplate.FindStringSubmatch(line); len(match) > 0 {
 			if p.startLBrk(match) {
@@ -179,7 +237,7 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 				}
 				curTmpl.AddStr(endl)
 			}
-			storeTemplate(into, curTmpl, pStr, dup)
+			store()
 			subtName := match[p.StartNameRgxGrp]
 			if strings.IndexRune(subtName, PathSep) >= 0 {
 				return fmt.Errorf(
@@ -197,7 +255,7 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 					"unexpected sub-template end '%s'",
 					subtName)
 			}
-			storeTemplate(into, curTmpl, pStr, dup)
+			store()
 			path, pStr = pPop(path)
 			curTmpl = into[pStr]
 			if p.endTBrk(match) {
@@ -205,6 +263,13 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 			} else {
 				endl = ""
 			}
+		} else if p.FlushPoint != nil && p.FlushPoint.MatchString(line) {
+			var err error
+			curTmpl, err = needTemplate(curTmpl, rootName, pStr)
+			if err != nil {
+				return err
+			}
+			curTmpl.Flush()
 		} else if match := p.BlockPh.FindStringSubmatch(line); len(match) > 0 {
 			var err error
 			curTmpl, err = needTemplate(curTmpl, rootName, pStr)
@@ -215,7 +280,9 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 				curTmpl.AddStr(endl)
 			}
 			phName := match[p.PhNameRgxGrp]
-			curTmpl.Ph(phName)
+			if err = p.addPh(curTmpl, phName); err != nil {
+				return fmt.Errorf("line %d: %s", lno, err)
+			}
 			if p.phTBrk(match) {
 				endl = p.Endl
 			} else {
@@ -231,7 +298,12 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 			if p.PrepLine != nil {
 				line = p.PrepLine(line)
 			}
-			p.addLine(curTmpl, line)
+			if p.wsMode(pStr) == WSTrimLines {
+				line = PrepTrimWS(line)
+			}
+			if err = p.addLine(curTmpl, line); err != nil {
+				return fmt.Errorf("line %d: %s", lno, err)
+			}
 			endl = p.Endl
 		}
 	}
@@ -239,7 +311,10 @@ func (p *Parser) Parse(rd io.Reader, rootName string, into map[string]*Template)
 		return fmt.Errorf("end of input in nested template: %s",
 			strings.Join(path, ", "))
 	}
-	storeTemplate(into, curTmpl, pStr, dup)
+	store()
+	for key := range parsed {
+		p.applyWS(into[key], p.wsMode(key))
+	}
 	if len(dup) > 0 {
 		return dup
 	} else {
@@ -261,7 +336,9 @@ func (p *Parser) addLine(t *Template, line string) error {
 				"unexpected end of line in placeholder '%s'",
 				line)
 		}
-		t.Ph(line[:tok])
+		if err := p.addPh(t, line[:tok]); err != nil {
+			return err
+		}
 		line = line[tok+len(p.EndInlinePh):]
 	}
 	if len(line) > 0 {
@@ -270,6 +347,18 @@ func (p *Parser) addLine(t *Template, line string) error {
 	return nil
 }
 
+func (p *Parser) addPh(t *Template, spec string) error {
+	if len(p.WrapSep) == 0 && len(p.AttrSep) == 0 {
+		t.Ph(spec)
+		return nil
+	}
+	d, err := ParsePhSpec(spec, p.WrapSep, p.AttrSep)
+	if err != nil {
+		return err
+	}
+	return t.AddPhDesc(d)
+}
+
 func (p *Parser) ParseFile(templateFile string, rootName string, into map[string]*Template) error {
 	tFile, err := os.Open(templateFile)
 	if err != nil {
@@ -3,14 +3,13 @@
 package goxic
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
 
 	"regexp"
 	"testing"
-
-	"github.com/stvp/assert"
 )
 
 func newTestParser() *Parser {
@@ -38,11 +37,11 @@ func assertEndls(t *testing.T,
 	fix []byte, expectLeading, expectTrailing bool,
 	hints ...interface{}) {
 	txt := string(fix)
-	assert.Equal(t,
+	assertEqual(t,
 		expectLeading,
 		strings.HasPrefix(txt, "\n"),
 		"leading linebreak")
-	assert.Equal(t,
+	assertEqual(t,
 		expectTrailing,
 		strings.HasSuffix(txt, "\n"),
 		"leading linebreak")
@@ -71,34 +70,34 @@ func ExampleRegexp() {
 func TestPathStr_nil(t *testing.T) {
 	var path []string = nil
 	pstr := pathStr(path)
-	assert.Equal(t, "", pstr)
+	assertEqual(t, "", pstr)
 }
 
 func TestPathStr_empty(t *testing.T) {
 	path := []string{}
 	pstr := pathStr(path)
-	assert.Equal(t, "", pstr)
+	assertEqual(t, "", pstr)
 }
 
 func TestPathStr_single(t *testing.T) {
 	path := []string{"foo"}
 	pstr := pathStr(path)
-	assert.Equal(t, "foo", pstr)
+	assertEqual(t, "foo", pstr)
 }
 
 func TestPathStr_two(t *testing.T) {
 	path := []string{"foo", "bar"}
 	pstr := pathStr(path)
-	assert.Equal(t, "foo/bar", pstr)
+	assertEqual(t, "foo/bar", pstr)
 }
 
 func TestParser_addLine(t *testing.T) {
 	tmpl := NewTemplate(t.Name())
 	p := newTestParser()
 	p.addLine(tmpl, "foo`bar`baz")
-	assert.Equal(t, 2, tmpl.FixCount())
-	assert.Equal(t, "foo", string(tmpl.FixAt(0)))
-	assert.Equal(t, "baz", string(tmpl.FixAt(1)))
+	assertEqual(t, 2, tmpl.FixCount())
+	assertEqual(t, "foo", string(tmpl.FixAt(0)))
+	assertEqual(t, "baz", string(tmpl.FixAt(1)))
 	assertIndices(t, tmpl.PhIdxs("bar"), 1)
 }
 
@@ -142,7 +141,7 @@ func Test1LineNoBrk(t *testing.T) {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		tmpl := ts[""]
-		assert.Equal(t, 1, tmpl.FixCount())
+		assertEqual(t, 1, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, false)
 	}
 }
@@ -155,7 +154,7 @@ func Test1LineLBrk(t *testing.T) {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		tmpl := ts[""]
-		assert.Equal(t, 1, tmpl.FixCount())
+		assertEqual(t, 1, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), true, false)
 	}
 }
@@ -168,7 +167,7 @@ func Test1LineTBrk(t *testing.T) {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		tmpl := ts[""]
-		assert.Equal(t, 1, tmpl.FixCount())
+		assertEqual(t, 1, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, true)
 	}
 }
@@ -181,7 +180,7 @@ func Test1LineLnTBrk(t *testing.T) {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		tmpl := ts[""]
-		assert.Equal(t, 1, tmpl.FixCount())
+		assertEqual(t, 1, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), true, true)
 	}
 }
@@ -197,7 +196,7 @@ line2`)
 	} else {
 		tmpl := ts[""]
 		assertIndices(t, tmpl.PhIdxs("phnm"), 1)
-		assert.Equal(t, 2, tmpl.FixCount())
+		assertEqual(t, 2, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, false)
 		assertEndls(t, tmpl.FixAt(1), false, false)
 	}
@@ -214,7 +213,7 @@ line2`)
 	} else {
 		tmpl := ts[""]
 		assertIndices(t, tmpl.PhIdxs("phnm"), 1)
-		assert.Equal(t, 2, tmpl.FixCount())
+		assertEqual(t, 2, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, true)
 		assertEndls(t, tmpl.FixAt(1), false, false)
 	}
@@ -231,7 +230,7 @@ line2`)
 	} else {
 		tmpl := ts[""]
 		assertIndices(t, tmpl.PhIdxs("phnm"), 1)
-		assert.Equal(t, 2, tmpl.FixCount())
+		assertEqual(t, 2, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, false)
 		assertEndls(t, tmpl.FixAt(1), true, false)
 	}
@@ -248,7 +247,7 @@ line2`)
 	} else {
 		tmpl := ts[""]
 		assertIndices(t, tmpl.PhIdxs("phnm"), 1)
-		assert.Equal(t, 2, tmpl.FixCount())
+		assertEqual(t, 2, tmpl.FixCount())
 		assertEndls(t, tmpl.FixAt(0), false, true)
 		assertEndls(t, tmpl.FixAt(1), true, false)
 	}
@@ -266,11 +265,11 @@ line2`)
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "line1line2", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "line1line2", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -286,11 +285,11 @@ line2`)
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "line1\nline2", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "line1\nline2", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -306,11 +305,11 @@ line2`)
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "line1\nline2", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "line1\nline2", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -326,11 +325,11 @@ line2`)
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "line1\n\nline2", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "line1\n\nline2", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -345,11 +344,11 @@ line2`)
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "\nline2", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "\nline2", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -364,11 +363,11 @@ subln
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		nestp := ts["sub"]
-		assert.Equal(t, 1, nestp.FixCount())
+		assertEqual(t, 1, nestp.FixCount())
 		assertEndls(t, nestp.FixAt(0), false, false)
 		rtmpl := ts[""]
-		assert.Equal(t, 1, rtmpl.FixCount())
-		assert.Equal(t, "line1\n", string(rtmpl.FixAt(0)))
+		assertEqual(t, 1, rtmpl.FixCount())
+		assertEqual(t, "line1\n", string(rtmpl.FixAt(0)))
 	}
 }
 
@@ -380,10 +379,12 @@ func TestOnlyPlaceholder(t *testing.T) {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
 		tp := ts[""]
-		assert.NotNil(t, tp)
-		assert.Equal(t, 0, tp.FixCount())
-		assert.Equal(t, 1, tp.PhNum())
-		assert.Equal(t, "foo", tp.PhAt(0))
+		if tp == nil {
+			t.Fatal("no template")
+		}
+		assertEqual(t, 0, tp.FixCount())
+		assertEqual(t, 1, tp.PhNum())
+		assertEqual(t, "foo", tp.PhAt(0))
 	}
 }
 
@@ -397,7 +398,7 @@ bar`)
 	if err := p.Parse(rd, t.Name(), ts); err != nil {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
-		assert.Equal(t, 1, len(ts))
+		assertEqual(t, 1, len(ts))
 	}
 }
 
@@ -415,11 +416,13 @@ func TestParser_prepline(t *testing.T) {
 	if err := p.Parse(rd, t.Name(), ts); err != nil {
 		t.Fatalf("cannot parse template: %s", err)
 	} else {
-		assert.Equal(t, 1, len(ts))
+		assertEqual(t, 1, len(ts))
 	}
 	prepped, ok := ts[""].Static()
-	assert.True(t, ok)
-	assert.Equal(t,
+	if !ok {
+		t.Error("template is not static")
+	}
+	assertEqual(t,
 		`<html>
 <head>
 <title>test prepline</title>
@@ -427,3 +430,88 @@ func TestParser_prepline(t *testing.T) {
 </html>`,
 		string(prepped))
 }
+
+func TestParser_wrappers(t *testing.T) {
+	rd := strings.NewReader("title: `title|trim|upper`\n<!-- >>> body|lower <<< -->")
+	p := NewSpecParser("`", "`", "<!--", "-->")
+	ts := make(map[string]*Template)
+	if err := p.Parse(rd, t.Name(), ts); err != nil {
+		t.Fatalf("cannot parse template: %s", err)
+	}
+	tmpl := ts[""]
+	assertIndices(t, tmpl.PhIdxs("title"), 1)
+	assertIndices(t, tmpl.PhIdxs("body"), 2)
+	assertEqual(t, []string{"trim", "upper"}, tmpl.WrapNamesAt(1))
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("title", "  Wrapped ")
+	bt.BindPName("body", "BODY")
+	out := bytes.NewBuffer(nil)
+	if _, err := CatchEmit(bt, out); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "title: WRAPPED\nbody", out.String())
+}
+
+func TestParser_plainNames(t *testing.T) {
+	rd := strings.NewReader("a `c|d` e `f;g`\n<!-- >>> x|y <<< -->\n<!-- ~~~ flush ~~~ -->")
+	p := NewParser("`", "`", "<!--", "-->")
+	ts := make(map[string]*Template)
+	if err := p.Parse(rd, t.Name(), ts); err != nil {
+		t.Fatalf("cannot parse template: %s", err)
+	}
+	tmpl := ts[""]
+	assertIndices(t, tmpl.PhIdxs("c|d"), 1)
+	assertIndices(t, tmpl.PhIdxs("f;g"), 2)
+	assertEqual(t, []string{"c|d", "f;g"}, sortedPhs(tmpl))
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("c|d", "C")
+	bt.BindPName("f;g", "F")
+	assertEqual(t,
+		"a C e F\n<!-- >>> x|y <<< -->\n<!-- ~~~ flush ~~~ -->",
+		emitString(t, bt))
+}
+
+func TestParser_repeatedWrapper(t *testing.T) {
+	rd := strings.NewReader("<t>`t|test-brackets`</t><h>`t|test-brackets`</h>")
+	p := NewSpecParser("`", "`", "<!--", "-->")
+	ts := make(map[string]*Template)
+	if err := p.Parse(rd, t.Name(), ts); err != nil {
+		t.Fatalf("cannot parse template: %s", err)
+	}
+	bt := ts[""].NewBounT(nil)
+	bt.BindPName("t", "x")
+	assertEqual(t, "<t>[x]</t><h>[x]</h>", emitString(t, bt))
+	bt = ts[""].NewInitBounT(Print{V: "y"}, nil)
+	assertEqual(t, "<t>[y]</t><h>[y]</h>", emitString(t, bt))
+}
+
+func TestParser_unknownWrapper(t *testing.T) {
+	rd := strings.NewReader("line 1\nline 2 `foo|no-such-wrapper`")
+	p := NewSpecParser("`", "`", "<!--", "-->")
+	err := p.Parse(rd, t.Name(), make(map[string]*Template))
+	if err == nil {
+		t.Fatal("expected unknown wrapper error")
+	}
+	assertEqual(t,
+		"line 2: placeholder 'foo': no wrapper 'no-such-wrapper'",
+		err.Error())
+}
+
+func TestParser_flush(t *testing.T) {
+	rd := strings.NewReader("<head>\n<!-- ~~~ flush ~~~ -->\n<p>`main;flush`</p>\nend")
+	p := NewSpecParser("`", "`", "<!--", "-->")
+	ts := make(map[string]*Template)
+	if err := p.Parse(rd, t.Name(), ts); err != nil {
+		t.Fatalf("cannot parse template: %s", err)
+	}
+	tmpl := ts[""]
+	assertEqual(t, true, tmpl.PhDesc("main").Flush)
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("main", "MAIN")
+	var fr flushRecorder
+	if _, err := CatchEmit(bt, &fr); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "<head>\n<p>MAIN</p>\nend", fr.String())
+	assertEqual(t, []string{"<head>", "<head>\n<p>MAIN"}, fr.flushed)
+}
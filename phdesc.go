@@ -0,0 +1,240 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PhKindBFT is the kind of placeholders that are filled by BounT.Fill.
+const PhKindBFT = "bft"
+
+// PhDesc describes a placeholder beyond its name. Placeholder descriptors
+// are parsed from the template source, see Parser.AttrSep, or set with
+// Template.AddPhDesc.
+type PhDesc struct {
+	// Name is the name used to bind content to the placeholder.
+	Name string
+	// Kind classifies the placeholder, e.g. PhKindBFT.
+	Kind string
+	// Format is a format specification for the placeholder's content.
+	Format string
+	// Wrappers are the names of the registered wrappers attached to the
+	// placeholder.
+	Wrappers []string
+	// Default is emitted when the placeholder is not bound. It is used only
+	// if not empty.
+	Default string
+	// An unbound Optional placeholder emits nothing instead of failing.
+	Optional bool
+	// Flush adds a flush point after the placeholder, see Template.Flush.
+	Flush bool
+	// Attrs holds all attributes without special meaning.
+	Attrs map[string]string
+}
+
+// Attributes with special meaning in a placeholder spec, see Parser.AttrSep.
+const (
+	PhAttrKind     = "kind"
+	PhAttrFormat   = "fmt"
+	PhAttrDefault  = "default"
+	PhAttrOptional = "opt"
+	PhAttrFlush    = "flush"
+)
+
+// String returns the descriptor in the placeholder syntax understood by
+// ParsePhSpec with wrapper separator "|" and attribute separator ";".
+func (d *PhDesc) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.Name)
+	for _, w := range d.Wrappers {
+		sb.WriteByte('|')
+		sb.WriteString(w)
+	}
+	attr := func(k, v string) {
+		sb.WriteByte(';')
+		sb.WriteString(k)
+		if len(v) > 0 {
+			sb.WriteByte('=')
+			sb.WriteString(v)
+		}
+	}
+	if len(d.Kind) > 0 && d.Kind != phKindOf(d.Name) {
+		attr(PhAttrKind, d.Kind)
+	}
+	if len(d.Format) > 0 && d.Format != phFormatOf(d.Name) {
+		attr(PhAttrFormat, d.Format)
+	}
+	if len(d.Default) > 0 {
+		attr(PhAttrDefault, d.Default)
+	}
+	if d.Optional {
+		attr(PhAttrOptional, "")
+	}
+	if d.Flush {
+		attr(PhAttrFlush, "")
+	}
+	keys := make([]string, 0, len(d.Attrs))
+	for k := range d.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attr(k, d.Attrs[k])
+	}
+	return sb.String()
+}
+
+// Kind and format of placeholders that follow the BFT naming convention
+// "$[format ]path".
+func phKindOf(name string) string {
+	if strings.HasPrefix(name, BftMarker) {
+		return PhKindBFT
+	}
+	return ""
+}
+
+func phFormatOf(name string) string {
+	if strings.HasPrefix(name, BftMarker) {
+		f, _ := bftSplitSpec(name[len(BftMarker):])
+		return f
+	}
+	return ""
+}
+
+// ParsePhSpec parses a placeholder spec of the form
+//
+//	name{<wrapSep>wrapper}{<attrSep>key[=value]}
+//
+// e.g. "title|trim|html;default=Untitled" with wrapSep "|" and attrSep ";".
+// The attributes kind, fmt, default, opt and flush set the respective
+// descriptor fields, all other attributes go to PhDesc.Attrs. Empty
+// separators disable the respective part of the syntax.
+func ParsePhSpec(spec, wrapSep, attrSep string) (*PhDesc, error) {
+	res := new(PhDesc)
+	var attrs []string
+	if len(attrSep) > 0 {
+		attrs = strings.Split(spec, attrSep)
+		spec, attrs = attrs[0], attrs[1:]
+	}
+	if len(wrapSep) > 0 {
+		ws := strings.Split(spec, wrapSep)
+		spec = ws[0]
+		if len(ws) > 1 {
+			res.Wrappers = ws[1:]
+		}
+	}
+	res.Name = spec
+	res.Kind = phKindOf(spec)
+	res.Format = phFormatOf(spec)
+	for _, attr := range attrs {
+		k, v := attr, ""
+		if sep := strings.IndexByte(attr, '='); sep >= 0 {
+			k, v = attr[:sep], attr[sep+1:]
+		}
+		switch k {
+		case "":
+			return nil, fmt.Errorf("placeholder '%s' has attribute without key", spec)
+		case PhAttrKind:
+			res.Kind = v
+		case PhAttrFormat:
+			res.Format = v
+		case PhAttrDefault:
+			res.Default = v
+		case PhAttrOptional:
+			res.Optional = true
+		case PhAttrFlush:
+			res.Flush = true
+		default:
+			if res.Attrs == nil {
+				res.Attrs = make(map[string]string)
+			}
+			res.Attrs[k] = v
+		}
+	}
+	return res, nil
+}
+
+// explicit reports whether the descriptor holds information that cannot be
+// derived from the placeholder name and its wrappers.
+func (d *PhDesc) explicit() bool {
+	return d.Kind != phKindOf(d.Name) ||
+		d.Format != phFormatOf(d.Name) ||
+		len(d.Default) > 0 ||
+		d.Optional ||
+		len(d.Attrs) > 0
+}
+
+// AddPhDesc adds a new placeholder described by d to the end of the
+// template. The wrappers named in d must be registered with
+// RegisterWrapper.
+func (t *Template) AddPhDesc(d *PhDesc) error {
+	for _, w := range d.Wrappers {
+		if LookupWrapper(w) == nil {
+			return fmt.Errorf("placeholder '%s': no wrapper '%s'", d.Name, w)
+		}
+	}
+	t.Ph(d.Name)
+	idx := len(t.plhAt) - 1
+	if len(d.Wrappers) > 0 {
+		if err := t.WrapNames(d.Wrappers, idx); err != nil {
+			return err
+		}
+	}
+	if d.explicit() {
+		t.setDescAt(idx, d)
+	}
+	if d.Flush {
+		t.Flush()
+	}
+	return nil
+}
+
+func (t *Template) setDescAt(idx int, d *PhDesc) {
+	for len(t.descAt) <= idx {
+		t.descAt = append(t.descAt, nil)
+	}
+	cp := *d
+	cp.Wrappers, cp.Flush = nil, false
+	if d.Attrs != nil {
+		cp.Attrs = make(map[string]string, len(d.Attrs))
+		for k, v := range d.Attrs {
+			cp.Attrs[k] = v
+		}
+	}
+	t.descAt[idx] = &cp
+}
+
+// PhDescAt returns the descriptor of the placeholder at position idx or nil
+// if there is no placeholder at idx. Placeholders that were added by name
+// only get a descriptor derived from their name and wrappers. The returned
+// descriptor is a copy, however its Attrs must not be modified.
+func (t *Template) PhDescAt(idx int) *PhDesc {
+	name := t.PhAt(idx)
+	if len(name) == 0 {
+		return nil
+	}
+	var res PhDesc
+	if idx < len(t.descAt) && t.descAt[idx] != nil {
+		res = *t.descAt[idx]
+	} else {
+		res.Kind = phKindOf(name)
+		res.Format = phFormatOf(name)
+	}
+	res.Name = name
+	res.Wrappers = t.WrapNamesAt(idx)
+	res.Flush = t.FlushAt(idx)
+	return &res
+}
+
+// PhDesc returns the descriptor of the first occurrence of the placeholder
+// with the given name or nil if there is no such placeholder.
+func (t *Template) PhDesc(name string) *PhDesc {
+	idxs := t.PhIdxs(name)
+	if len(idxs) == 0 {
+		return nil
+	}
+	return t.PhDescAt(idxs[0])
+}
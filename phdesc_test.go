@@ -0,0 +1,125 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePhSpec(t *testing.T) {
+	d, err := ParsePhSpec("title|trim|upper;default=None;opt;role=heading", "|", ";")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := PhDesc{
+		Name:     "title",
+		Wrappers: []string{"trim", "upper"},
+		Default:  "None",
+		Optional: true,
+		Attrs:    map[string]string{"role": "heading"},
+	}
+	if !reflect.DeepEqual(*d, expect) {
+		t.Errorf("wrong descriptor %+v", *d)
+	}
+	if s := d.String(); s != "title|trim|upper;default=None;opt;role=heading" {
+		t.Errorf("wrong string '%s'", s)
+	}
+	if d, _ = ParsePhSpec("main;flush", "|", ";"); !d.Flush || d.String() != "main;flush" {
+		t.Errorf("wrong flush descriptor %+v", *d)
+	}
+	if _, err = ParsePhSpec("foo;=bar", "|", ";"); err == nil {
+		t.Error("expected error for attribute without key")
+	}
+}
+
+func TestParsePhSpec_bft(t *testing.T) {
+	d, err := ParsePhSpec("$%05d Addrs.-1.No", "|", ";")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Kind != PhKindBFT || d.Format != "%05d" {
+		t.Errorf("wrong BFT descriptor %+v", *d)
+	}
+	d, err = ParsePhSpec("$Addrs.-1.No;fmt=%05d", "|", ";")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "$Addrs.-1.No" || d.Kind != PhKindBFT || d.Format != "%05d" {
+		t.Errorf("wrong BFT descriptor %+v", *d)
+	}
+}
+
+func TestTemplate_PhDescAt(t *testing.T) {
+	p := NewSpecParser("`", "`", "<!--", "-->")
+	ts := make(map[string]*Template)
+	err := p.Parse(
+		strings.NewReader("`a|upper;opt` `b;kind=text;x=y` `$%d Count`"),
+		t.Name(),
+		ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := ts[""]
+	if d := tmpl.PhDesc("a"); d == nil || !d.Optional ||
+		!reflect.DeepEqual(d.Wrappers, []string{"upper"}) {
+		t.Errorf("wrong descriptor for a: %+v", d)
+	}
+	if d := tmpl.PhDesc("b"); d == nil || d.Kind != "text" || d.Attrs["x"] != "y" {
+		t.Errorf("wrong descriptor for b: %+v", d)
+	}
+	if d := tmpl.PhDesc("$%d Count"); d == nil || d.Kind != PhKindBFT || d.Format != "%d" {
+		t.Errorf("wrong descriptor for BFT: %+v", d)
+	}
+	if d := tmpl.PhDescAt(3); d != nil {
+		t.Errorf("unexpected descriptor without placeholder: %+v", d)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Template
+	if err = restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, nm := range tmpl.Phs() {
+		if !reflect.DeepEqual(tmpl.PhDesc(nm), restored.PhDesc(nm)) {
+			t.Errorf("restored descriptor differs: %+v", restored.PhDesc(nm))
+		}
+	}
+}
+
+func TestBounT_Emit_optional(t *testing.T) {
+	tmpl := NewTemplate(t.Name())
+	tmpl.AddStr("[")
+	tmpl.AddPhDesc(&PhDesc{Name: "opt", Optional: true})
+	tmpl.AddStr("]")
+	bt := tmpl.NewBounT(nil)
+	var buf bytes.Buffer
+	if _, err := CatchEmit(bt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]" {
+		t.Errorf("wrong output '%s'", buf.String())
+	}
+}
+
+func ExamplePhDesc_Default() {
+	tmpl := NewTemplate("default")
+	tmpl.AddStr("Hello ")
+	tmpl.AddPhDesc(&PhDesc{Name: "who", Wrappers: []string{"upper"}, Default: "world"})
+	tmpl.AddStr("!\n")
+	bt := tmpl.NewBounT(nil)
+	bt.Emit(os.Stdout)
+	bt.BindPName("who", "goxic")
+	bt.Emit(os.Stdout)
+	fmt.Println(tmpl.PhDesc("who"))
+	// Output:
+	// Hello WORLD!
+	// Hello GOXIC!
+	// who|upper;default=world
+}
@@ -0,0 +1,364 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Templates and template sets are serialized into a compact binary format
+// that starts with a magic string followed by a format version. Integers are
+// written as unsigned varints, strings and fragments are prefixed with their
+// length. Wrappers are stored by their registered names, see RegisterWrapper.
+const (
+	tmplMagic      = "gxt"
+	tmplSetMagic   = "gxs"
+	tmplSerVersion = 1
+)
+
+var errSerTruncated = errors.New("goxic: truncated serialized template")
+
+type serWriter struct {
+	bytes.Buffer
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func (w *serWriter) uint(i int) {
+	n := binary.PutUvarint(w.tmp[:], uint64(i))
+	w.Write(w.tmp[:n])
+}
+
+func (w *serWriter) bytes(b []byte) {
+	w.uint(len(b))
+	w.Write(b)
+}
+
+func (w *serWriter) str(s string) {
+	w.uint(len(s))
+	w.WriteString(s)
+}
+
+type serReader struct {
+	*bytes.Reader
+}
+
+func (r serReader) uint() (int, error) {
+	i, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return 0, errSerTruncated
+	} else if err != nil {
+		return 0, err
+	}
+	if i > uint64(r.Len()) {
+		// no count or length can exceed the remaining input
+		return 0, errSerTruncated
+	}
+	return int(i), nil
+}
+
+func (r serReader) bytes() ([]byte, error) {
+	l, err := r.uint()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]byte, l)
+	if _, err = io.ReadFull(r, res); err != nil {
+		return nil, errSerTruncated
+	}
+	return res, nil
+}
+
+func (r serReader) str() (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+func (r serReader) header(magic string) error {
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, m); err != nil || string(m) != magic {
+		return fmt.Errorf("goxic: no serialized data, expect magic '%s'", magic)
+	}
+	v, err := r.ReadByte()
+	if err != nil {
+		return errSerTruncated
+	}
+	if v != tmplSerVersion {
+		return fmt.Errorf("goxic: unsupported serialization version %d", v)
+	}
+	return nil
+}
+
+func (t *Template) marshal(w *serWriter) error {
+	w.str(t.Name)
+	w.uint(len(t.fix))
+	for _, f := range t.fix {
+		w.bytes(f)
+	}
+	w.uint(len(t.plhAt))
+	for _, ph := range t.plhAt {
+		w.str(ph)
+	}
+	w.uint(len(t.escAt))
+	for i, esc := range t.escAt {
+		nms := t.WrapNamesAt(i)
+		if esc != nil && len(nms) == 0 {
+			return fmt.Errorf(
+				"goxic: template '%s' has unnamed wrapper at %d",
+				t.Name,
+				i)
+		}
+		w.uint(len(nms))
+		for _, nm := range nms {
+			w.str(nm)
+		}
+	}
+	w.uint(len(t.descAt))
+	for _, d := range t.descAt {
+		if d == nil {
+			w.uint(0)
+			continue
+		}
+		w.uint(1)
+		w.str(d.Kind)
+		w.str(d.Format)
+		w.str(d.Default)
+		if d.Optional {
+			w.uint(1)
+		} else {
+			w.uint(0)
+		}
+		keys := make([]string, 0, len(d.Attrs))
+		for k := range d.Attrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.uint(len(keys))
+		for _, k := range keys {
+			w.str(k)
+			w.str(d.Attrs[k])
+		}
+	}
+	w.str(t.MediaType)
+	w.uint(len(t.flushAt))
+	for _, f := range t.flushAt {
+		if f {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	}
+	return nil
+}
+
+func (r serReader) desc() (d *PhDesc, err error) {
+	if set, err := r.uint(); err != nil || set == 0 {
+		return nil, err
+	}
+	d = new(PhDesc)
+	if d.Kind, err = r.str(); err != nil {
+		return nil, err
+	}
+	if d.Format, err = r.str(); err != nil {
+		return nil, err
+	}
+	if d.Default, err = r.str(); err != nil {
+		return nil, err
+	}
+	opt, err := r.uint()
+	if err != nil {
+		return nil, err
+	}
+	d.Optional = opt != 0
+	n, err := r.uint()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		d.Attrs = make(map[string]string, n)
+	}
+	for i := 0; i < n; i++ {
+		k, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		if d.Attrs[k], err = r.str(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler which also makes
+// templates usable with encoding/gob. Serialization fails if the template
+// has wrappers that were not attached by their registered name.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	var w serWriter
+	w.WriteString(tmplMagic)
+	w.WriteByte(tmplSerVersion)
+	if err := t.marshal(&w); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// posCount reads the length of a per position table, which must not exceed
+// the len(t.fix)+1 positions of the already restored fragments.
+func (t *Template) posCount(r serReader, what string) (int, error) {
+	n, err := r.uint()
+	if err != nil {
+		return 0, err
+	}
+	if n > len(t.fix)+1 {
+		return 0, fmt.Errorf("goxic: too many %s in template '%s'", what, t.Name)
+	}
+	return n, nil
+}
+
+func (t *Template) unmarshal(r serReader) (err error) {
+	if t.Name, err = r.str(); err != nil {
+		return err
+	}
+	n, err := r.uint()
+	if err != nil {
+		return err
+	}
+	t.fix = make([]fragment, n)
+	for i := range t.fix {
+		if t.fix[i], err = r.bytes(); err != nil {
+			return err
+		}
+	}
+	if n, err = t.posCount(r, "placeholders"); err != nil {
+		return err
+	}
+	t.plhAt = make([]string, n)
+	t.plhNm2Idxs = make(map[string][]int)
+	for i := range t.plhAt {
+		if t.plhAt[i], err = r.str(); err != nil {
+			return err
+		}
+		if ph := t.plhAt[i]; len(ph) > 0 {
+			t.plhNm2Idxs[ph] = append(t.plhNm2Idxs[ph], i)
+		}
+	}
+	if n, err = t.posCount(r, "wrappers"); err != nil {
+		return err
+	}
+	t.escAt, t.wrapNm = nil, nil
+	for i := 0; i < n; i++ {
+		l, err := r.uint()
+		if err != nil {
+			return err
+		}
+		if l == 0 {
+			continue
+		}
+		nms := make([]string, l)
+		for j := range nms {
+			if nms[j], err = r.str(); err != nil {
+				return err
+			}
+		}
+		if err = t.WrapNames(nms, i); err != nil {
+			return fmt.Errorf("goxic: cannot restore template '%s': %s",
+				t.Name,
+				err)
+		}
+	}
+	t.descAt = nil
+	if n, err = t.posCount(r, "placeholder descriptors"); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		d, err := r.desc()
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			t.setDescAt(i, d)
+		}
+	}
+	if t.MediaType, err = r.str(); err != nil {
+		return err
+	}
+	if n, err = t.posCount(r, "flush points"); err != nil {
+		return err
+	}
+	t.flushAt = make([]bool, n)
+	for i := range t.flushAt {
+		f, err := r.ReadByte()
+		if err != nil {
+			return errSerTruncated
+		}
+		t.flushAt[i] = f != 0
+	}
+	return nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. All wrappers
+// referenced by the serialized template must be registered with
+// RegisterWrapper.
+func (t *Template) UnmarshalBinary(data []byte) error {
+	r := serReader{bytes.NewReader(data)}
+	if err := r.header(tmplMagic); err != nil {
+		return err
+	}
+	return t.unmarshal(r)
+}
+
+// WriteTemplates serializes a whole set of templates, e.g. the result of
+// Parser.Parse, to wr.
+func WriteTemplates(wr io.Writer, ts map[string]*Template) error {
+	keys := make([]string, 0, len(ts))
+	for k := range ts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var w serWriter
+	w.WriteString(tmplSetMagic)
+	w.WriteByte(tmplSerVersion)
+	w.uint(len(keys))
+	for _, k := range keys {
+		w.str(k)
+		if err := ts[k].marshal(&w); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteTo(wr)
+	return err
+}
+
+// ReadTemplates reads a set of templates written with WriteTemplates and
+// stores them into the map into. Existing entries with the same key are
+// replaced.
+func ReadTemplates(rd io.Reader, into map[string]*Template) error {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	r := serReader{bytes.NewReader(data)}
+	if err = r.header(tmplSetMagic); err != nil {
+		return err
+	}
+	n, err := r.uint()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		k, err := r.str()
+		if err != nil {
+			return err
+		}
+		t := new(Template)
+		if err = t.unmarshal(r); err != nil {
+			return err
+		}
+		into[k] = t
+	}
+	return nil
+}
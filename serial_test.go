@@ -0,0 +1,166 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterWrapper("test-brackets", func(c Content) Content {
+		e := Embrace("[", c, "]")
+		return &e
+	})
+}
+
+func emitString(t *testing.T, bt *BounT) string {
+	var buf bytes.Buffer
+	if _, err := CatchEmit(bt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestTemplate_MarshalBinary(t *testing.T) {
+	tmpl := NewTemplate("ser").Ph("a").AddStr("foo").Ph("b").AddStr("bar").Ph("a")
+	tmpl.MediaType = "text/plain"
+	tmpl.Flush()
+	if err := tmpl.WrapName("test-brackets", tmpl.PhIdxs("b")...); err != nil {
+		t.Fatal(err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Template
+	if err = restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Name != "ser" {
+		t.Errorf("wrong name '%s'", restored.Name)
+	}
+	if restored.MediaType != "text/plain" {
+		t.Errorf("wrong media type '%s'", restored.MediaType)
+	}
+	assertIndices(t, restored.PhIdxs("a"), 0, 2)
+	assertIndices(t, restored.PhIdxs("b"), 1)
+	if !restored.FlushAt(2) {
+		t.Error("lost flush point")
+	}
+	if nms := restored.WrapNamesAt(1); len(nms) != 1 || nms[0] != "test-brackets" {
+		t.Errorf("wrong wrapper names %v", nms)
+	}
+	bt := restored.NewBounT(nil)
+	bt.BindPName("a", "A")
+	bt.BindPName("b", "B")
+	if out := emitString(t, bt); out != "Afoo[B]barA" {
+		t.Errorf("wrong output '%s'", out)
+	}
+}
+
+func TestTemplate_MarshalBinary_unnamedWrapper(t *testing.T) {
+	tmpl := NewTemplate("anon").AddStr("foo").Ph("bar")
+	tmpl.Wrap(func(c Content) Content { return c }, tmpl.PhIdxs("bar")...)
+	if _, err := tmpl.MarshalBinary(); err == nil {
+		t.Error("expected error for unnamed wrapper")
+	}
+}
+
+func TestTemplate_UnmarshalBinary_corrupt(t *testing.T) {
+	tmpl := NewTemplate("corrupt").AddStr("foo").Ph("bar").AddStr("baz")
+	data, _ := tmpl.MarshalBinary()
+	var restored Template
+	if err := restored.UnmarshalBinary(data[:len(data)-2]); err == nil {
+		t.Error("expected error for truncated data")
+	}
+	if err := restored.UnmarshalBinary([]byte("no template")); err == nil {
+		t.Error("expected error for missing magic")
+	}
+}
+
+func TestTemplate_UnmarshalBinary_positions(t *testing.T) {
+	corrupt := func(plhs, wraps int) []byte {
+		var w serWriter
+		w.WriteString(tmplMagic)
+		w.WriteByte(tmplSerVersion)
+		w.str("corrupt")
+		w.uint(1)
+		w.str("foo")
+		w.uint(plhs)
+		for i := 0; i < plhs; i++ {
+			w.str("p")
+		}
+		w.uint(wraps)
+		for i := 0; i < wraps; i++ {
+			w.uint(0)
+		}
+		w.uint(0)
+		w.str("")
+		w.uint(0)
+		return w.Bytes()
+	}
+	var restored Template
+	if err := restored.UnmarshalBinary(corrupt(2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.UnmarshalBinary(corrupt(3, 0)); err == nil {
+		t.Error("expected error for too many placeholders")
+	}
+	if err := restored.UnmarshalBinary(corrupt(1, 3)); err == nil {
+		t.Error("expected error for too many wrappers")
+	}
+}
+
+func TestTemplate_gob(t *testing.T) {
+	tmpl := NewTemplate("gob").AddStr("foo").Ph("bar").AddStr("baz")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(tmpl); err != nil {
+		t.Fatal(err)
+	}
+	var restored *Template
+	if err := gob.NewDecoder(&buf).Decode(&restored); err != nil {
+		t.Fatal(err)
+	}
+	bt := restored.NewBounT(nil)
+	bt.BindPName("bar", "-")
+	if out := emitString(t, bt); out != "foo-baz" {
+		t.Errorf("wrong output '%s'", out)
+	}
+}
+
+func ExampleWriteTemplates() {
+	ts := make(map[string]*Template)
+	err := newTestParser().Parse(strings.NewReader(`root `+"`x`"+`
+<!--\ >>> sub >>> -->
+sub `+"`y`"+`
+<!-- <<< sub <<< -->`), "doc", ts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var buf bytes.Buffer
+	if err = WriteTemplates(&buf, ts); err != nil {
+		fmt.Println(err)
+		return
+	}
+	loaded := make(map[string]*Template)
+	if err = ReadTemplates(&buf, loaded); err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, key := range []string{"", "sub"} {
+		bt := loaded[key].NewInitBounT(Print{"#"}, nil)
+		fmt.Printf("%s: ", loaded[key].Name)
+		bt.Emit(os.Stdout)
+		io.WriteString(os.Stdout, "\n")
+	}
+	// Output:
+	// doc: root #
+	// doc/sub: sub #
+}
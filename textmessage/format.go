@@ -0,0 +1,139 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Formatter creates the content for BFT placeholders using a
+// message.Printer, i.e. numbers are formatted for the printer's locale and
+// month and day names of dates are translated with the printer's catalog.
+// Use Formatter.Content with goxic.BounT.FillWith or
+// goxic.TypedTemplate.FillWith.
+type Formatter struct {
+	Printer *message.Printer
+	// Layouts maps BFT formats to time layouts for time.Time values. The
+	// empty format selects the layout for time values without format. A
+	// format that is not in Layouts and has no '%' is used as layout
+	// itself. Month and day names are looked up in the printer's catalog
+	// by their English names from the layout, e.g. "March" for "January"
+	// or "Wed" for "Mon".
+	Layouts map[string]string
+	// Lang is the language for the plural rules.
+	Lang language.Tag
+	// Cases are the case sets referenced by the BFT formats "plural:name"
+	// and "select:name", see Plural and Select.
+	Cases map[string]Cases
+}
+
+const (
+	PluralFormat = "plural:"
+	SelectFormat = "select:"
+)
+
+// NewFormatter creates a Formatter for language lang. The Printer is
+// created with message.NewPrinter from lang and opts, e.g. a
+// message.Catalog.
+func NewFormatter(lang language.Tag, layouts map[string]string, opts ...message.Option) *Formatter {
+	return &Formatter{
+		Printer: message.NewPrinter(lang, opts...),
+		Layouts: layouts,
+		Lang:    lang,
+	}
+}
+
+// Content creates the content for value v formatted with format.
+func (f *Formatter) Content(format string, v interface{}) goxic.Content {
+	if name, ok := strings.CutPrefix(format, PluralFormat); ok {
+		cases, err := f.cases(name)
+		if err != nil {
+			return errContent{err}
+		}
+		return Plural{Printer: f.Printer, Lang: f.Lang, Cases: cases, N: v}
+	}
+	if name, ok := strings.CutPrefix(format, SelectFormat); ok {
+		cases, err := f.cases(name)
+		if err != nil {
+			return errContent{err}
+		}
+		return Select{Printer: f.Printer, Cases: cases, Key: v}
+	}
+	if t, ok := v.(time.Time); ok {
+		if layout, ok := f.Layouts[format]; ok {
+			return goxic.Print{V: f.formatTime(t, layout)}
+		}
+		if len(format) > 0 && !strings.Contains(format, "%") {
+			return goxic.Print{V: f.formatTime(t, format)}
+		}
+	}
+	if len(format) == 0 {
+		format = "%v"
+	}
+	return Content{f.Printer, format, []interface{}{v}}
+}
+
+// formatTime formats t with layout and translates the month and day names.
+func (f *Formatter) formatTime(t time.Time, layout string) string {
+	var sb strings.Builder
+	for {
+		idx, std := nextTimeName(layout)
+		if idx < 0 {
+			sb.WriteString(t.Format(layout))
+			return sb.String()
+		}
+		sb.WriteString(t.Format(layout[:idx]))
+		sb.WriteString(f.Printer.Sprintf(timeNames[std](t)))
+		layout = layout[idx+len(std):]
+	}
+}
+
+var timeNames = map[string]func(time.Time) string{
+	"January": func(t time.Time) string { return t.Month().String() },
+	"Jan":     func(t time.Time) string { return t.Month().String()[:3] },
+	"Monday":  func(t time.Time) string { return t.Weekday().String() },
+	"Mon":     func(t time.Time) string { return t.Weekday().String()[:3] },
+}
+
+// nextTimeName finds the first month or day name element in layout.
+func nextTimeName(layout string) (idx int, std string) {
+	idx = strings.Index(layout, "Jan")
+	if mon := strings.Index(layout, "Mon"); mon >= 0 && (idx < 0 || mon < idx) {
+		idx = mon
+	}
+	if idx < 0 {
+		return -1, ""
+	}
+	for _, std = range []string{"January", "Monday", "Jan", "Mon"} {
+		if strings.HasPrefix(layout[idx:], std) {
+			break
+		}
+	}
+	return idx, std
+}
+
+func (f *Formatter) cases(name string) (Cases, error) {
+	if cases, ok := f.Cases[name]; ok {
+		return cases, nil
+	}
+	return nil, fmt.Errorf("unknown case set '%s'", name)
+}
+
+type errContent struct{ err error }
+
+func (ec errContent) Emit(wr io.Writer) int {
+	panic(goxic.EmitError{Err: ec.err})
+}
+
+// Fill binds all BFT placeholders of bt from data using f, see
+// goxic.BounT.FillWith.
+func (f *Formatter) Fill(bt *goxic.BounT, data interface{}, overwrite bool) (missed int, err error) {
+	return bt.FillWith(data, overwrite, f.Content)
+}
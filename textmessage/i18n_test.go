@@ -0,0 +1,165 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+func emitString(t *testing.T, bt *goxic.BounT) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := goxic.CatchEmit(bt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestLocaleSet_Lookup(t *testing.T) {
+	ls := NewLocaleSet(language.English)
+	en := goxic.NewTemplate("en")
+	de := goxic.NewTemplate("de")
+	deAT := goxic.NewTemplate("de-AT")
+	ls.Add(language.English, map[string]*goxic.Template{"a": en, "b": en})
+	ls.Add(language.German, map[string]*goxic.Template{"a": de})
+	ls.Add(language.MustParse("de-AT"), map[string]*goxic.Template{"b": deAT})
+	tests := []struct {
+		tag, name string
+		expect    *goxic.Template
+		from      string
+	}{
+		{"de-AT", "a", de, "de"},
+		{"de-AT", "b", deAT, "de-AT"},
+		{"de-CH", "b", en, "en"},
+		{"fr", "a", en, "en"},
+	}
+	for _, test := range tests {
+		tmpl, from := ls.Lookup(language.MustParse(test.tag), test.name)
+		if tmpl != test.expect {
+			t.Errorf("%s/%s: wrong template %v", test.tag, test.name, tmpl)
+		}
+		if from.String() != test.from {
+			t.Errorf("%s/%s: found in %s, expected %s", test.tag, test.name, from, test.from)
+		}
+	}
+	if tmpl, _ := ls.Lookup(language.German, "c"); tmpl != nil {
+		t.Errorf("unexpected template %v", tmpl)
+	}
+	fbs := ls.Fallbacks(language.MustParse("de-AT"))
+	if !reflect.DeepEqual(fbs, []language.Tag{
+		language.MustParse("de-AT"),
+		language.German,
+		language.English,
+	}) {
+		t.Errorf("wrong fallbacks %v", fbs)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tmpl := goxic.NewTemplate("extract").
+		AddStr("<h1>[[Welcome]]</h1>").Ph("name").
+		AddStr("[[Bye]] [[Welcome]]")
+	msgs, err := Extract(tmpl, DefaultMarks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(msgs, []string{"Welcome", "Bye"}) {
+		t.Errorf("wrong messages %v", msgs)
+	}
+	tmpl = goxic.NewTemplate("bad").AddStr("[[Hello ").Ph("name").AddStr("]]")
+	if _, err = Extract(tmpl, DefaultMarks); err == nil {
+		t.Error("no error for translatable text across placeholder")
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	cat := catalog.NewBuilder()
+	cat.SetString(language.German, "Welcome", "Willkommen")
+	tmpl := goxic.NewTemplate("greet").AddStr("[[Welcome]], ").Ph("name").AddStr("!")
+	ls := NewLocaleSet(language.English)
+	for _, tag := range []language.Tag{language.English, language.German} {
+		err := ls.AddLocalized(tag,
+			map[string]*goxic.Template{"greet": tmpl},
+			DefaultMarks,
+			message.Catalog(cat))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for tag, expect := range map[string]string{
+		"en":    "Welcome, John!",
+		"de-AT": "Willkommen, John!",
+	} {
+		lt, _ := ls.Lookup(language.MustParse(tag), "greet")
+		bt := lt.NewBounT(nil)
+		bt.BindPName("name", "John")
+		if s := emitString(t, bt); s != expect {
+			t.Errorf("%s: expected '%s', got '%s'", tag, expect, s)
+		}
+	}
+	if s := string(tmpl.FixAt(0)); s != "[[Welcome]], " {
+		t.Errorf("original template modified: '%s'", s)
+	}
+}
+
+func TestFormatter_date(t *testing.T) {
+	cat := catalog.NewBuilder()
+	cat.SetString(language.German, "March", "März")
+	cat.SetString(language.German, "Wed", "Mi")
+	cat.SetString(language.German, "Wednesday", "Mittwoch")
+	f := NewFormatter(language.German,
+		map[string]string{"long": "Monday, 2. January 2006"},
+		message.Catalog(cat))
+	due := time.Date(2018, 3, 21, 0, 0, 0, 0, time.UTC)
+	for format, expect := range map[string]string{
+		"long":       "Mittwoch, 21. März 2018",
+		"Mon 02 Jan": "Mi 21 Mar",
+		"2006-01-02": "2018-03-21",
+		"Jan Monday": "Mar Mittwoch",
+	} {
+		bt := goxic.NewTemplate("date").Ph("d").NewBounT(nil)
+		bt.BindName("d", f.Content(format, due))
+		if s := emitString(t, bt); s != expect {
+			t.Errorf("%s: expected '%s', got '%s'", format, expect, s)
+		}
+	}
+}
+
+func ExampleFormatter() {
+	tmpl := goxic.NewTemplate("fmt").
+		Ph("$Amount").AddStr(" | ").
+		Ph("$%.2f Price").AddStr(" | ").
+		Ph("$date Due")
+	data := struct {
+		Amount int
+		Price  float64
+		Due    time.Time
+	}{1234567, 4711.5, time.Date(2018, 3, 21, 0, 0, 0, 0, time.UTC)}
+	for _, loc := range []struct {
+		tag    language.Tag
+		layout string
+	}{
+		{language.English, "01/02/2006"},
+		{language.German, "02.01.2006"},
+	} {
+		f := NewFormatter(loc.tag, map[string]string{
+			"date": loc.layout,
+		})
+		bt := tmpl.NewBounT(nil)
+		f.Fill(bt, data, true)
+		bt.Emit(os.Stdout)
+		os.Stdout.WriteString("\n")
+	}
+	// Output:
+	// 1,234,567 | 4,711.50 | 03/21/2018
+	// 1.234.567 | 4.711,50 | 21.03.2018
+}
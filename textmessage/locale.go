@@ -0,0 +1,98 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"sort"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// LocaleSet holds variants of named templates for different locales. A
+// template is looked up along the fallback chain of the requested locale,
+// e.g. de-AT → de → Default.
+type LocaleSet struct {
+	Default language.Tag
+	locs    map[language.Tag]map[string]*goxic.Template
+}
+
+func NewLocaleSet(def language.Tag) *LocaleSet {
+	return &LocaleSet{
+		Default: def,
+		locs:    make(map[language.Tag]map[string]*goxic.Template),
+	}
+}
+
+// Add adds the templates ts for locale tag. Templates already present for
+// tag with the same name are replaced.
+func (ls *LocaleSet) Add(tag language.Tag, ts map[string]*goxic.Template) {
+	loc := ls.locs[tag]
+	if loc == nil {
+		loc = make(map[string]*goxic.Template)
+		ls.locs[tag] = loc
+	}
+	for nm, t := range ts {
+		loc[nm] = t
+	}
+}
+
+// AddLocalized localizes the translatable text of the templates ts with a
+// message.Printer for tag and adds the results for tag. The templates ts
+// are not modified.
+func (ls *LocaleSet) AddLocalized(
+	tag language.Tag,
+	ts map[string]*goxic.Template,
+	marks Marks,
+	opts ...message.Option,
+) error {
+	pr := message.NewPrinter(tag, opts...)
+	lts := make(map[string]*goxic.Template, len(ts))
+	for nm, t := range ts {
+		lt, err := Localize(pr, t, marks)
+		if err != nil {
+			return err
+		}
+		lts[nm] = lt
+	}
+	ls.Add(tag, lts)
+	return nil
+}
+
+// Tags returns the locales that have templates, sorted by their string
+// representation. Use them e.g. to create a language.Matcher.
+func (ls *LocaleSet) Tags() []language.Tag {
+	res := make([]language.Tag, 0, len(ls.locs))
+	for tag := range ls.locs {
+		res = append(res, tag)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+// Fallbacks returns the locales that are searched for templates of locale
+// tag in order: tag itself, its parents and finally the set's Default.
+func (ls *LocaleSet) Fallbacks(tag language.Tag) (res []language.Tag) {
+	for ; tag != language.Und; tag = tag.Parent() {
+		res = append(res, tag)
+	}
+	for _, t := range res {
+		if t == ls.Default {
+			return res
+		}
+	}
+	return append(res, ls.Default)
+}
+
+// Lookup returns the template name for locale tag along with the locale
+// it was found for. It returns nil if no locale in tag's fallback chain
+// has such a template.
+func (ls *LocaleSet) Lookup(tag language.Tag, name string) (*goxic.Template, language.Tag) {
+	for _, fb := range ls.Fallbacks(tag) {
+		if t, ok := ls.locs[fb][name]; ok {
+			return t, fb
+		}
+	}
+	return nil, language.Und
+}
@@ -0,0 +1,160 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Cases maps cases to message formats. For Plural a case is either "=N"
+// to match the number N exactly or one of the CLDR plural forms "zero",
+// "one", "two", "few", "many" and "other". For Select a case is the
+// selector value as printed with fmt.Sprint. With both, "other" is used if
+// no other case matches.
+type Cases map[string]string
+
+const otherCase = "other"
+
+var formNames = map[plural.Form]string{
+	plural.Other: otherCase,
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+}
+
+// Plural is content that selects its message format from Cases by the
+// plural form of N in language Lang. The selected format is printed with
+// Printer and Values. If Values is nil, N is the only value.
+type Plural struct {
+	Printer *message.Printer
+	Lang    language.Tag
+	Cases   Cases
+	N       interface{}
+	Values  []interface{}
+}
+
+func (p Plural) Emit(wr io.Writer) int {
+	return p.EmitCtx(context.Background(), wr)
+}
+
+func (p Plural) EmitCtx(ctx context.Context, wr io.Writer) int {
+	num, err := decimal(p.N)
+	if err != nil {
+		panic(goxic.EmitError{Err: err})
+	}
+	format, ok := p.Cases["="+num]
+	if !ok {
+		format, ok = p.Cases[formNames[pluralForm(p.Lang, num)]]
+	}
+	if !ok {
+		format, ok = p.Cases[otherCase]
+	}
+	if !ok {
+		panic(goxic.EmitError{Err: fmt.Errorf("no plural case for %s", num)})
+	}
+	vals := p.Values
+	if vals == nil {
+		vals = []interface{}{p.N}
+	}
+	return Content{p.Printer, format, vals}.EmitCtx(ctx, wr)
+}
+
+// Select is content that selects its message format from Cases by Key,
+// e.g. a gender or an enum value. The selected format is printed with
+// Printer and Values. If Values is nil, Key is the only value.
+type Select struct {
+	Printer *message.Printer
+	Cases   Cases
+	Key     interface{}
+	Values  []interface{}
+}
+
+func (s Select) Emit(wr io.Writer) int {
+	return s.EmitCtx(context.Background(), wr)
+}
+
+func (s Select) EmitCtx(ctx context.Context, wr io.Writer) int {
+	key := fmt.Sprint(s.Key)
+	format, ok := s.Cases[key]
+	if !ok {
+		format, ok = s.Cases[otherCase]
+	}
+	if !ok {
+		panic(goxic.EmitError{Err: fmt.Errorf("no select case for '%s'", key)})
+	}
+	vals := s.Values
+	if vals == nil {
+		vals = []interface{}{s.Key}
+	}
+	return Content{s.Printer, format, vals}.EmitCtx(ctx, wr)
+}
+
+// decimal returns the plain decimal representation of the number n.
+func decimal(n interface{}) (string, error) {
+	switch n := n.(type) {
+	case int:
+		return strconv.FormatInt(int64(n), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(n), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(n), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(n), 10), nil
+	case int64:
+		return strconv.FormatInt(n, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(n), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(n), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(n), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(n), 10), nil
+	case uint64:
+		return strconv.FormatUint(n, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}
+	str := fmt.Sprint(n)
+	if _, err := strconv.ParseFloat(str, 64); err != nil {
+		return "", fmt.Errorf("plural of non-number '%s'", str)
+	}
+	return str, nil
+}
+
+// pluralForm computes the plural operands of the decimal num and matches
+// them against the cardinal plural rules of lang.
+func pluralForm(lang language.Tag, num string) plural.Form {
+	num = strings.TrimPrefix(num, "-")
+	ipart, fpart, _ := strings.Cut(num, ".")
+	fvis := strings.TrimRight(fpart, "0")
+	return plural.Cardinal.MatchPlural(lang,
+		operand(ipart),
+		len(fpart),
+		len(fvis),
+		operand(fpart),
+		operand(fvis))
+}
+
+// operand returns the decimal digits ds as int modulo 10,000,000.
+func operand(ds string) (res int) {
+	for _, d := range ds {
+		if '0' <= d && d <= '9' {
+			res = (10*res + int(d-'0')) % 10000000
+		}
+	}
+	return res
+}
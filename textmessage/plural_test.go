@@ -0,0 +1,123 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestPlural(t *testing.T) {
+	en := message.NewPrinter(language.English)
+	ru := message.NewPrinter(language.Russian)
+	enCases := Cases{"=0": "no items", "one": "%v item", "other": "%v items"}
+	ruCases := Cases{"one": "%d файл", "few": "%d файла", "many": "%d файлов"}
+	tests := []struct {
+		cnt    Plural
+		expect string
+	}{
+		{Plural{en, language.English, enCases, 0, nil}, "no items"},
+		{Plural{en, language.English, enCases, 1, nil}, "1 item"},
+		{Plural{en, language.English, enCases, uint8(3), nil}, "3 items"},
+		{Plural{en, language.English, enCases, 1.0, nil}, "1 item"},
+		{Plural{en, language.English, enCases, "1.0", nil}, "1.0 items"},
+		{Plural{en, language.English, enCases, 1.5, nil}, "1.5 items"},
+		{Plural{ru, language.Russian, ruCases, 21, nil}, "21 файл"},
+		{Plural{ru, language.Russian, ruCases, 3, nil}, "3 файла"},
+		{Plural{ru, language.Russian, ruCases, 11, nil}, "11 файлов"},
+		{Plural{en, language.English, enCases, 2, []interface{}{"x"}}, "x items"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if _, err := goxic.CatchEmit(plainBounT(test.cnt), &buf); err != nil {
+			t.Errorf("%v: %s", test.cnt.N, err)
+		} else if buf.String() != test.expect {
+			t.Errorf("%v: expected '%s', got '%s'", test.cnt.N, test.expect, buf.String())
+		}
+	}
+	_, err := goxic.CatchEmit(plainBounT(Plural{en, language.English, enCases, "many", nil}), &bytes.Buffer{})
+	if err == nil {
+		t.Error("no error for plural of non-number")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	pr := message.NewPrinter(language.English)
+	cases := Cases{"female": "her", "male": "his", "other": "their"}
+	for key, expect := range map[string]string{
+		"female": "her",
+		"male":   "his",
+		"none":   "their",
+	} {
+		var buf bytes.Buffer
+		goxic.CatchEmit(plainBounT(Select{pr, cases, key, nil}), &buf)
+		if buf.String() != expect {
+			t.Errorf("%s: expected '%s', got '%s'", key, expect, buf.String())
+		}
+	}
+	_, err := goxic.CatchEmit(plainBounT(Select{pr, Cases{}, "x", nil}), &bytes.Buffer{})
+	if err == nil {
+		t.Error("no error without matching case")
+	}
+}
+
+func plainBounT(cnt goxic.Content) *goxic.BounT {
+	return goxic.NewTemplate("plain").Ph("x").NewInitBounT(cnt, nil)
+}
+
+func TestFormatter_plural(t *testing.T) {
+	f := NewFormatter(language.Russian, nil)
+	f.Cases = map[string]Cases{
+		"files": {"one": "%d файл", "few": "%d файла", "many": "%d файлов"},
+	}
+	tmpl := goxic.NewTemplate("files").Ph("$plural:files N")
+	for n, expect := range map[int]string{1: "1 файл", 3: "3 файла", 11: "11 файлов"} {
+		bt := tmpl.NewBounT(nil)
+		if _, err := f.Fill(bt, struct{ N int }{n}, true); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := goxic.CatchEmit(bt, &buf); err != nil {
+			t.Errorf("%d: %s", n, err)
+		} else if buf.String() != expect {
+			t.Errorf("%d: expected '%s', got '%s'", n, expect, buf.String())
+		}
+	}
+}
+
+func ExampleFormatter_plural() {
+	tmpl := goxic.NewTemplate("inbox").
+		Ph("$select:gender User.Gender").AddStr(" inbox has ").
+		Ph("$plural:mails Mails")
+	f := &Formatter{
+		Printer: message.NewPrinter(language.English),
+		Lang:    language.English,
+		Cases: map[string]Cases{
+			"gender": {"female": "Her", "male": "His", "other": "Their"},
+			"mails":  {"=0": "no mails", "one": "one mail", "other": "%d mails"},
+		},
+	}
+	type user struct{ Gender string }
+	for _, data := range []struct {
+		User  user
+		Mails int
+	}{
+		{user{"female"}, 1},
+		{user{"male"}, 0},
+		{user{""}, 1200},
+	} {
+		bt := tmpl.NewBounT(nil)
+		f.Fill(bt, data, true)
+		bt.Emit(os.Stdout)
+		os.Stdout.WriteString("\n")
+	}
+	// Output:
+	// Her inbox has one mail
+	// His inbox has no mails
+	// Their inbox has 1,200 mails
+}
@@ -0,0 +1,82 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package textmessage
+
+import (
+	"bytes"
+	"fmt"
+
+	"codeberg.org/fractalqb/goxic"
+	"golang.org/x/text/message"
+)
+
+// Marks delimit translatable text in the fixed fragments of templates.
+// Translatable text must not span placeholders. The marked text is used
+// as message key and is a format without arguments, i.e. a literal '%'
+// has to be written as '%%'.
+type Marks struct {
+	Start, End string
+}
+
+var DefaultMarks = Marks{Start: "[[", End: "]]"}
+
+// Extract returns the translatable text of template t in order of first
+// occurrence.
+func Extract(t *goxic.Template, marks Marks) (msgs []string, err error) {
+	seen := make(map[string]bool)
+	for i := 0; i < t.FixCount(); i++ {
+		_, err = marks.xform(t, t.FixAt(i), func(msg string) string {
+			if !seen[msg] {
+				seen[msg] = true
+				msgs = append(msgs, msg)
+			}
+			return msg
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msgs, nil
+}
+
+// Localize returns a copy of t where all translatable text is replaced by
+// its translation from pr. The marks are removed.
+func Localize(pr *message.Printer, t *goxic.Template, marks Marks) (*goxic.Template, error) {
+	res := t.Clone()
+	err := res.XformFixs(func(frag []byte) ([]byte, error) {
+		return marks.xform(t, frag, func(msg string) string {
+			return pr.Sprintf(msg)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m Marks) xform(t *goxic.Template, frag []byte, x func(string) string) ([]byte, error) {
+	start, end := []byte(m.Start), []byte(m.End)
+	if len(start) == 0 || len(end) == 0 {
+		return nil, fmt.Errorf("template '%s': empty translation mark", t.Name)
+	}
+	sidx := bytes.Index(frag, start)
+	if sidx < 0 {
+		return frag, nil
+	}
+	var buf bytes.Buffer
+	for sidx >= 0 {
+		buf.Write(frag[:sidx])
+		frag = frag[sidx+len(start):]
+		eidx := bytes.Index(frag, end)
+		if eidx < 0 {
+			return nil, fmt.Errorf("template '%s': unterminated translatable text '%s'",
+				t.Name,
+				frag)
+		}
+		buf.WriteString(x(string(frag[:eidx])))
+		frag = frag[eidx+len(end):]
+		sidx = bytes.Index(frag, start)
+	}
+	buf.Write(frag)
+	return buf.Bytes(), nil
+}
@@ -3,9 +3,10 @@
 package textmessage
 
 import (
+	"context"
 	"io"
 
-	"git.fractalqb.de/fractalqb/goxic"
+	"codeberg.org/fractalqb/goxic"
 	"golang.org/x/text/message"
 )
 
@@ -15,10 +16,17 @@ type Content struct {
 	Values  []interface{}
 }
 
-func (c Content) Emit(wr io.Writer) (n int) {
+func (c Content) Emit(wr io.Writer) int {
+	return c.EmitCtx(context.Background(), wr)
+}
+
+func (c Content) EmitCtx(ctx context.Context, wr io.Writer) int {
+	if err := ctx.Err(); err != nil {
+		panic(goxic.EmitError{Err: err})
+	}
 	n, err := c.Printer.Fprintf(wr, c.Format, c.Values...)
 	if err != nil {
-		panic(goxic.EmitError{n, err})
+		panic(goxic.EmitError{Count: n, Err: err})
 	} else {
 		return n
 	}
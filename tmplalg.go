@@ -0,0 +1,112 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"fmt"
+)
+
+// Clone creates a deep copy of the template.
+func (t *Template) Clone() *Template {
+	res := NewTemplate(t.Name)
+	res.MediaType = t.MediaType
+	res.appendRange(t, 0, len(t.fix)+1, nil)
+	return res
+}
+
+// Append appends the fixed fragments and placeholders of other to the end
+// of t. Placeholders of other keep their wrappers and descriptors.
+// Placeholders with the same name in t and other get merged.
+func (t *Template) Append(other *Template) *Template {
+	t.appendRange(other, 0, len(other.fix)+1, nil)
+	return t
+}
+
+// Concat creates a new template with the given name from the concatenation
+// of the templates ts. The new template has the media type of the first
+// template.
+func Concat(name string, ts ...*Template) *Template {
+	res := NewTemplate(name)
+	if len(ts) > 0 {
+		res.MediaType = ts[0].MediaType
+	}
+	for _, t := range ts {
+		res.Append(t)
+	}
+	return res
+}
+
+// Slice returns a new template with the positions from ≤ i < to of t. Each
+// position i consists of the placeholder at i – if any – followed by the
+// fixed fragment at i. Position FixCount() only has the trailing
+// placeholder. This way Concat of t.Slice(0, i) and t.Slice(i, t.FixCount()+1)
+// is equivalent to t.
+func (t *Template) Slice(from, to int) *Template {
+	if from < 0 || to > len(t.fix)+1 || from > to {
+		panic(fmt.Sprintf("goxic: slice [%d:%d] out of range in template '%s'",
+			from,
+			to,
+			t.Name))
+	}
+	res := NewTemplate(t.Name)
+	res.MediaType = t.MediaType
+	res.appendRange(t, from, to, nil)
+	return res
+}
+
+// Splice returns a new template where each occurrence of the placeholder ph
+// in t is replaced by the content of the template sub. Placeholders of sub
+// that collide with other placeholders in t are renamed by prepending
+// prefix. With an empty prefix colliding placeholders get merged.
+func (t *Template) Splice(ph string, sub *Template, prefix string) (*Template, error) {
+	if t.PhIdxs(ph) == nil {
+		return nil, renameErr{ph}
+	}
+	subNames := make(map[string]string)
+	for _, sph := range sub.Phs() {
+		if sph != ph && t.PhIdxs(sph) != nil {
+			subNames[sph] = prefix + sph
+		}
+	}
+	res := NewTemplate(t.Name)
+	res.MediaType = t.MediaType
+	for idx := 0; idx <= len(t.fix); idx++ {
+		if t.PhAt(idx) == ph {
+			res.appendRange(sub, 0, len(sub.fix)+1, subNames)
+			if t.FlushAt(idx) {
+				res.Flush()
+			}
+			if idx < len(t.fix) {
+				res.AddFix(cloneFrag(t.fix[idx]))
+			}
+		} else {
+			res.appendRange(t, idx, idx+1, nil)
+		}
+	}
+	return res, nil
+}
+
+// appendRange appends the positions from ≤ i < to of src to t, see Slice.
+// Placeholder names found in rename are replaced with the mapped name.
+func (t *Template) appendRange(src *Template, from, to int, rename map[string]string) {
+	for idx := from; idx < to; idx++ {
+		if ph := src.PhAt(idx); len(ph) > 0 {
+			if nm, ok := rename[ph]; ok {
+				ph = nm
+			}
+			t.phFrom(src, idx, ph)
+		}
+		if src.FlushAt(idx) {
+			t.Flush()
+		}
+		if idx < len(src.fix) {
+			t.AddFix(cloneFrag(src.fix[idx]))
+		}
+	}
+}
+
+func cloneFrag(f fragment) fragment {
+	res := make(fragment, len(f))
+	copy(res, f)
+	return res
+}
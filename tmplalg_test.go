@@ -0,0 +1,118 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func algTestTemplate() *Template {
+	tmpl := NewTemplate("alg").Ph("a").AddStr("1").Ph("b").AddStr("2").Ph("a")
+	tmpl.WrapName("upper", tmpl.PhIdxs("b")...)
+	tmpl.MediaType = "text/x-alg"
+	return tmpl
+}
+
+func TestTemplate_Clone(t *testing.T) {
+	tmpl := algTestTemplate()
+	clone := tmpl.Clone()
+	clone.fix[0][0] = 'X'
+	clone.RenamePh("a", "c", false)
+	assertEqual(t, "1", string(tmpl.FixAt(0)))
+	assertIndices(t, tmpl.PhIdxs("a"), 0, 2)
+	assertIndices(t, clone.PhIdxs("c"), 0, 2)
+	assertEqual(t, []string{"upper"}, clone.WrapNamesAt(1))
+	assertEqual(t, "text/x-alg", clone.MediaType)
+	assertEqual(t, "text/x-alg", Concat("cat", clone, NewTemplate("")).MediaType)
+	assertEqual(t, "text/x-alg", clone.NewBounT(nil).Fixate().MediaType)
+}
+
+func TestTemplate_Clone_deep(t *testing.T) {
+	tmpl := algTestTemplate()
+	tmpl.AddPhDesc(&PhDesc{Name: "d", Attrs: map[string]string{"k": "v"}})
+	didx := tmpl.PhIdxs("d")[0]
+	clone := tmpl.Clone()
+	tmpl.wrapNm[1][0] = "lower"
+	tmpl.descAt[didx].Attrs["k"] = "changed"
+	assertEqual(t, []string{"upper"}, clone.WrapNamesAt(1))
+	assertEqual(t, "v", clone.PhDescAt(didx).Attrs["k"])
+}
+
+func TestTemplate_Slice(t *testing.T) {
+	tmpl := algTestTemplate()
+	n := tmpl.FixCount() + 1
+	for i := 0; i <= n; i++ {
+		cat := Concat("alg", tmpl.Slice(0, i), tmpl.Slice(i, n))
+		bt := cat.NewBounT(nil)
+		bt.BindPName("a", "a")
+		bt.BindPName("b", "b")
+		assertEqual(t, "a1B2a", emitString(t, bt), "split at", i)
+	}
+	s := tmpl.Slice(1, 2)
+	assertEqual(t, []string{"b"}, s.Phs())
+	assertIndices(t, s.PhIdxs("b"), 0)
+	assertEqual(t, "2", string(s.FixAt(0)))
+}
+
+func TestTemplate_Splice(t *testing.T) {
+	tmpl := algTestTemplate()
+	sub := NewTemplate("sub").AddStr("(").Ph("b").AddStr(",").Ph("c").AddStr(")")
+	res, err := tmpl.Splice("a", sub, "sub.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, []string{"b", "c", "sub.b"}, sortedPhs(res))
+	bt := res.NewBounT(nil)
+	bt.BindPName("b", "b")
+	bt.BindPName("c", "c")
+	bt.BindPName("sub.b", "x")
+	assertEqual(t, "(x,c)1B2(x,c)", emitString(t, bt))
+	if _, err = tmpl.Splice("nope", sub, ""); !RenameUnknown(err) {
+		t.Errorf("expected unknown placeholder error, got %v", err)
+	}
+}
+
+func TestTemplate_flush(t *testing.T) {
+	tmpl := NewTemplate("page").AddStr("<").Flush().Ph("a").AddStr(">").Flush()
+	sub := NewTemplate("sub").Ph("x").Flush().AddStr("|").Ph("y")
+	res, err := tmpl.Splice("a", sub, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []*Template{tmpl.Clone(), res, Concat("cat", tmpl.Slice(0, 2), tmpl.Slice(2, 4))} {
+		bt := tt.NewBounT(nil)
+		bt.BindPName("a", "A")
+		bt.BindPName("x", "X")
+		bt.BindPName("y", "Y")
+		var fr flushRecorder
+		bt.Emit(&fr)
+		switch tt {
+		case res:
+			assertEqual(t, []string{"<", "<X", "<X|Y>"}, fr.flushed, tt.Name)
+		default:
+			assertEqual(t, []string{"<", "<A>"}, fr.flushed, tt.Name)
+		}
+	}
+	bt := tmpl.NewBounT(nil)
+	bt.BindPName("a", "A")
+	fix := bt.Fixate()
+	assertEqual(t, true, fix.FlushAt(1))
+	assertEqual(t, true, fix.FlushAt(2))
+}
+
+func ExampleConcat() {
+	header := NewTemplate("header").AddStr("Dear ").Ph("name").AddStr(",\n")
+	body := NewTemplate("body").AddStr("your order ").Ph("order").AddStr(" shipped.\n")
+	mail := Concat("mail", header, body)
+	bt := mail.NewBounT(nil)
+	bt.BindPName("name", "Jane")
+	bt.BindPName("order", 4711)
+	if _, err := CatchEmit(bt, os.Stdout); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// Dear Jane,
+	// your order 4711 shipped.
+}
@@ -0,0 +1,221 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypedTemplate binds the BFT placeholders of a template from values of
+// type T. Other than BounT.Fill, all BFT placeholder paths are resolved
+// against the type T once when the TypedTemplate is created. Binding then
+// does no name lookup: It follows the precomputed field indices with
+// package reflect, i.e. binding still pays for reflective value access.
+type TypedTemplate[T any] struct {
+	tmpl  *Template
+	binds []typedBind
+}
+
+type typedStep func(reflect.Value) (reflect.Value, bool)
+
+type typedBind struct {
+	idxs   []int
+	format string
+	steps  []typedStep
+}
+
+// BftPathError describes a BFT placeholder whose path does not resolve on
+// the type of a TypedTemplate.
+type BftPathError struct {
+	Placeholder string
+	Path        string
+	Reason      string
+}
+
+// BftPathErrors lists all BFT placeholders of template T that cannot be
+// resolved on Type.
+type BftPathErrors struct {
+	T    *Template
+	Type reflect.Type
+	Errs []BftPathError
+}
+
+func (e *BftPathErrors) Error() string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "template '%s' does not match type %s:", e.T.Name, e.Type)
+	for i, pe := range e.Errs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, " '%s' %s", pe.Path, pe.Reason)
+	}
+	return buf.String()
+}
+
+// NewTypedTemplate creates a TypedTemplate for the BFT placeholders of
+// tmpl. It returns a *BftPathErrors if any BFT placeholder path cannot be
+// resolved on type T. Paths must not lead through interface types because
+// they cannot be checked in advance.
+func NewTypedTemplate[T any](tmpl *Template) (*TypedTemplate[T], error) {
+	res := &TypedTemplate[T]{tmpl: tmpl}
+	ty := reflect.TypeOf((*T)(nil)).Elem()
+	var perrs []BftPathError
+	for ph, idxs := range tmpl.plhNm2Idxs {
+		if len(idxs) == 0 || !strings.HasPrefix(ph, BftMarker) {
+			continue
+		}
+		d := tmpl.PhDescAt(idxs[0])
+		if d.Kind != PhKindBFT {
+			continue
+		}
+		_, path := bftSplitSpec(ph[len(BftMarker):])
+		steps, err := typedCompile(ty, path)
+		if err != nil {
+			perrs = append(perrs, BftPathError{
+				Placeholder: ph,
+				Path:        path,
+				Reason:      err.Error(),
+			})
+			continue
+		}
+		res.binds = append(res.binds, typedBind{
+			idxs:   idxs,
+			format: d.Format,
+			steps:  steps,
+		})
+	}
+	if len(perrs) > 0 {
+		sort.Slice(perrs, func(i, j int) bool { return perrs[i].Path < perrs[j].Path })
+		return nil, &BftPathErrors{T: tmpl, Type: ty, Errs: perrs}
+	}
+	return res, nil
+}
+
+// MustTypedTemplate is like NewTypedTemplate but panics on error.
+func MustTypedTemplate[T any](tmpl *Template) *TypedTemplate[T] {
+	res, err := NewTypedTemplate[T](tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (tt *TypedTemplate[T]) Template() *Template { return tt.tmpl }
+
+// Fill binds all BFT placeholders of bt from data. The bound template must
+// have been created from tt's template. Placeholders whose path does not
+// lead to a value, e.g. because of an index out of range, are not bound and
+// counted in missed.
+func (tt *TypedTemplate[T]) Fill(bt *BounT, data T) (missed int) {
+	return tt.FillWith(bt, data, nil)
+}
+
+// FillWith is like Fill but uses mk to create the bound content, see
+// BounT.FillWith.
+func (tt *TypedTemplate[T]) FillWith(bt *BounT, data T, mk BftContent) (missed int) {
+	if mk == nil {
+		mk = bftDefault
+	}
+	if bt.Template() != tt.tmpl {
+		panic("goxic: typed fill of foreign template " + bt.Template().Name)
+	}
+	root := reflect.ValueOf(&data).Elem()
+	for _, b := range tt.binds {
+		v, ok := root, true
+		for _, step := range b.steps {
+			if v, ok = step(v); !ok {
+				break
+			}
+		}
+		if !ok {
+			missed++
+		} else {
+			bt.Bind(b.idxs, mk(b.format, v.Interface()))
+		}
+	}
+	return missed
+}
+
+// NewBounT creates a new bound template with all BFT placeholders filled
+// from data.
+func (tt *TypedTemplate[T]) NewBounT(data T, reuse *BounT) *BounT {
+	res := tt.tmpl.NewBounT(reuse)
+	tt.Fill(res, data)
+	return res
+}
+
+func typedCompile(ty reflect.Type, path string) (steps []typedStep, err error) {
+	for _, seg := range strings.Split(path, BftPathSep) {
+		for ty.Kind() == reflect.Ptr {
+			steps = append(steps, typedDeref)
+			ty = ty.Elem()
+		}
+		if idx, err := strconv.Atoi(seg); err == nil {
+			switch ty.Kind() {
+			case reflect.Array, reflect.Slice:
+				steps = append(steps, typedIndex(idx))
+				ty = ty.Elem()
+			default:
+				return nil, fmt.Errorf("segment '%s' requires slice or array, got %s",
+					seg,
+					ty.Kind())
+			}
+		} else {
+			switch ty.Kind() {
+			case reflect.Map:
+				if ty.Key().Kind() != reflect.String {
+					return nil, fmt.Errorf("segment '%s' requires string map key, got %s",
+						seg,
+						ty.Key())
+				}
+				key := reflect.ValueOf(seg).Convert(ty.Key())
+				steps = append(steps, func(v reflect.Value) (reflect.Value, bool) {
+					v = v.MapIndex(key)
+					return v, v.IsValid()
+				})
+				ty = ty.Elem()
+			case reflect.Struct:
+				f, ok := ty.FieldByName(seg)
+				if !ok || len(f.PkgPath) > 0 {
+					return nil, fmt.Errorf("no exported field '%s' in %s", seg, ty)
+				}
+				fidx := f.Index
+				steps = append(steps, func(v reflect.Value) (reflect.Value, bool) {
+					v, err := v.FieldByIndexErr(fidx)
+					return v, err == nil
+				})
+				ty = f.Type
+			default:
+				return nil, fmt.Errorf("segment '%s' requires map or struct, got %s",
+					seg,
+					ty.Kind())
+			}
+		}
+	}
+	return steps, nil
+}
+
+func typedDeref(v reflect.Value) (reflect.Value, bool) {
+	if v.IsNil() {
+		return v, false
+	}
+	return v.Elem(), true
+}
+
+func typedIndex(idx int) typedStep {
+	return func(v reflect.Value) (reflect.Value, bool) {
+		i := idx
+		if i < 0 {
+			i += v.Len()
+		}
+		if i < 0 || i >= v.Len() {
+			return v, false
+		}
+		return v.Index(i), true
+	}
+}
@@ -0,0 +1,72 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+type typedAddr struct {
+	Street string
+	No     int
+}
+
+type typedData struct {
+	Name  string
+	Addrs []typedAddr
+	Tags  map[string]string
+	Boss  *typedData
+}
+
+func ExampleTypedTemplate() {
+	tmpl := NewTemplate("typed example").
+		AddStr("Name: ").Ph("$Name").
+		AddStr("\nLast Address: ").Ph("$Addrs.-1.Street").
+		AddStr(" ").Ph("$%05d Addrs.-1.No").
+		AddStr("\nBoss: ").Ph("$Boss.Name")
+	tt := MustTypedTemplate[typedData](tmpl)
+	data := typedData{
+		Name: "John Doe",
+		Addrs: []typedAddr{
+			{Street: "Cansas Lane", No: 1},
+			{Street: "Yellow-Brick-Road", No: 33},
+		},
+		Boss: &typedData{Name: "Jane Roe"},
+	}
+	if _, err := CatchEmit(tt.NewBounT(data, nil), os.Stdout); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// Name: John Doe
+	// Last Address: Yellow-Brick-Road 00033
+	// Boss: Jane Roe
+}
+
+func TestTypedTemplate_badPaths(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).
+		Ph("$Name").Ph("$Nmae").Ph("$Addrs.Street").Ph("$Tags.x").Ph("plain")
+	_, err := NewTypedTemplate[typedData](tmpl)
+	perrs, ok := err.(*BftPathErrors)
+	if !ok {
+		t.Fatalf("expected path errors, got %v", err)
+	}
+	bad := make(map[string]bool)
+	for _, pe := range perrs.Errs {
+		bad[pe.Path] = true
+	}
+	assertEqual(t, map[string]bool{"Nmae": true, "Addrs.Street": true}, bad)
+}
+
+func TestTypedTemplate_missed(t *testing.T) {
+	tmpl := NewTemplate(t.Name()).Ph("$Boss.Name").Ph("$Addrs.0.No").Ph("$Tags.x")
+	tt := MustTypedTemplate[typedData](tmpl)
+	bt := tmpl.NewBounT(nil)
+	assertEqual(t, 3, tt.Fill(bt, typedData{}))
+	assertEqual(t, 0, tt.Fill(bt, typedData{
+		Boss:  &typedData{},
+		Addrs: []typedAddr{{No: 1}},
+		Tags:  map[string]string{"x": "y"},
+	}))
+}
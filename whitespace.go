@@ -0,0 +1,174 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+)
+
+// WSMode selects how the Parser treats whitespace in fixed fragments.
+type WSMode int
+
+const (
+	// WSPreserve keeps all whitespace.
+	WSPreserve WSMode = iota
+	// WSTrimLines removes leading and trailing blanks from each line, like
+	// PrepTrimWS.
+	WSTrimLines
+	// WSCollapse replaces each run of whitespace with a single newline, if
+	// the run contains a newline, or with a single space otherwise.
+	WSCollapse
+	// WSMinifyHTML replaces each run of whitespace with a single space
+	// except within the elements pre, textarea, script and style and within
+	// quoted attribute values.
+	WSMinifyHTML
+)
+
+func (p *Parser) wsMode(path string) WSMode {
+	if m, ok := p.WSByPath[path]; ok {
+		return m
+	}
+	return p.WS
+}
+
+func (p *Parser) applyWS(t *Template, mode WSMode) {
+	switch mode {
+	case WSCollapse:
+		for i, f := range t.fix {
+			t.fix[i] = collapseWS(f, nil)
+		}
+	case WSMinifyHTML:
+		var st htmlWS
+		for i, f := range t.fix {
+			t.fix[i] = collapseWS(f, &st)
+		}
+	}
+}
+
+var wsRawElems = [][]byte{
+	[]byte("pre"),
+	[]byte("textarea"),
+	[]byte("script"),
+	[]byte("style"),
+}
+
+func isWS(b byte) bool {
+	switch b {
+	case ' ', '	', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
+
+func isTagName(b byte, first bool) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z':
+		return true
+	case '0' <= b && b <= '9', b == '-':
+		return !first
+	}
+	return false
+}
+
+// htmlWS is the state of HTML minification that carries over from one
+// fixed fragment to the next.
+type htmlWS struct {
+	raw   []byte // name of the open raw text element
+	name  []byte // tag name read so far, nil if not reading a tag name
+	inTag bool
+	quote byte // quote of the open attribute value
+}
+
+// collapseWS collapses the whitespace runs in frag. With html not nil,
+// whitespace is kept in raw text elements and in quoted attribute values.
+func collapseWS(frag fragment, html *htmlWS) fragment {
+	res := make(fragment, 0, len(frag))
+	for i := 0; i < len(frag); {
+		c := frag[i]
+		switch {
+		case html == nil:
+		case len(html.raw) > 0:
+			end := indexFoldClose(frag[i:], html.raw)
+			if end < 0 {
+				return append(res, frag[i:]...)
+			}
+			end += i + 2 + len(html.raw)
+			res = append(res, frag[i:end]...)
+			html.raw, html.inTag, i = nil, true, end
+			continue
+		case html.quote != 0:
+			end := bytes.IndexByte(frag[i:], html.quote)
+			if end < 0 {
+				return append(res, frag[i:]...)
+			}
+			end += i + 1
+			res = append(res, frag[i:end]...)
+			html.quote, i = 0, end
+			continue
+		case html.name != nil:
+			if isTagName(c, len(html.name) == 0) {
+				html.name = append(html.name, c)
+				res = append(res, c)
+				i++
+				continue
+			}
+			if len(html.name) == 0 {
+				html.inTag = c == '/'
+			} else {
+				html.raw = rawElem(html.name)
+			}
+			html.name = nil
+			if c == '/' && html.inTag {
+				res = append(res, c)
+				i++
+			}
+			continue
+		case html.inTag && (c == '"' || c == '\''):
+			html.quote = c
+		case html.inTag && c == '>':
+			html.inTag = false
+		case !html.inTag && c == '<':
+			html.inTag, html.name = true, []byte{}
+		}
+		if !isWS(c) {
+			res = append(res, c)
+			i++
+			continue
+		}
+		sep := byte(' ')
+		for ; i < len(frag) && isWS(frag[i]); i++ {
+			if frag[i] == '\n' && html == nil {
+				sep = '\n'
+			}
+		}
+		res = append(res, sep)
+	}
+	return res
+}
+
+func rawElem(name []byte) []byte {
+	for _, e := range wsRawElems {
+		if bytes.EqualFold(name, e) {
+			return e
+		}
+	}
+	return nil
+}
+
+// indexFoldClose finds the closing tag "</elem" case-insensitively. The
+// element name must be followed by '>', whitespace or the end of frag.
+func indexFoldClose(frag, elem []byte) int {
+	for i := bytes.Index(frag, []byte("</")); i >= 0; {
+		if rest := frag[i+2:]; len(rest) >= len(elem) &&
+			bytes.EqualFold(rest[:len(elem)], elem) &&
+			(len(rest) == len(elem) || rest[len(elem)] == '>' || isWS(rest[len(elem)])) {
+			return i
+		}
+		next := bytes.Index(frag[i+2:], []byte("</"))
+		if next < 0 {
+			return -1
+		}
+		i += 2 + next
+	}
+	return -1
+}
@@ -0,0 +1,81 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"strings"
+	"testing"
+)
+
+func parseWS(t *testing.T, mode WSMode, src string) map[string]*Template {
+	p := NewParser("`", "`", "<!--", "-->")
+	p.WS = mode
+	ts := make(map[string]*Template)
+	if err := p.Parse(strings.NewReader(src), t.Name(), ts); err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestParser_WSTrimLines(t *testing.T) {
+	ts := parseWS(t, WSTrimLines, "  <p>\n    `x`  foo\t\n  </p>  ")
+	bt := ts[""].NewInitBounT(Print{"X"}, nil)
+	assertEqual(t, "<p>\nX  foo\n</p>", emitString(t, bt))
+}
+
+func TestParser_WSCollapse(t *testing.T) {
+	ts := parseWS(t, WSCollapse, "a  b\n\n   c `x`   d")
+	bt := ts[""].NewInitBounT(Print{"X  Y"}, nil)
+	assertEqual(t, "a b\nc X  Y d", emitString(t, bt))
+}
+
+func TestParser_WSMinifyHTML(t *testing.T) {
+	ts := parseWS(t, WSMinifyHTML, `<div>
+    <p>  Hello  `+"`name`"+`  </p>
+    <PRE class="code">
+  keep   this
+</pre>
+    <textarea>`+"`text`"+`  stays
+   here</textarea>
+</div>`)
+	bt := ts[""].NewInitBounT(Print{"  X  "}, nil)
+	assertEqual(t,
+		`<div> <p> Hello   X   </p> <PRE class="code">
+  keep   this
+</pre> <textarea>  X    stays
+   here</textarea> </div>`,
+		emitString(t, bt))
+}
+
+func TestParser_WSMinifyHTML_split(t *testing.T) {
+	ts := parseWS(t, WSMinifyHTML, "<pre`attrs`>  keep   this  </pre>   x")
+	bt := ts[""].NewInitBounT(Print{` class="c"`}, nil)
+	assertEqual(t, `<pre class="c">  keep   this  </pre> x`, emitString(t, bt))
+}
+
+func TestParser_WSMinifyHTML_prefix(t *testing.T) {
+	ts := parseWS(t, WSMinifyHTML, "<pre>a  </prefix>  b</pre>   c")
+	assertEqual(t, "<pre>a  </prefix>  b</pre> c", string(ts[""].FixAt(0)))
+}
+
+func TestParser_WSMinifyHTML_attr(t *testing.T) {
+	ts := parseWS(t, WSMinifyHTML, `<p  title="a   b" alt='`+"`x`"+`  c'>  d  </p>`)
+	bt := ts[""].NewInitBounT(Print{"X"}, nil)
+	assertEqual(t, `<p title="a   b" alt='X  c'> d </p>`, emitString(t, bt))
+}
+
+func TestParser_WSByPath(t *testing.T) {
+	p := NewParser("`", "`", "<!--", "-->")
+	p.WS = WSCollapse
+	p.WSByPath = map[string]WSMode{"sub": WSPreserve}
+	ts := make(map[string]*Template)
+	err := p.Parse(strings.NewReader(`a   b
+<!--\ >>> sub >>> -->
+c   d
+<!-- <<< sub <<< \-->`), t.Name(), ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "a b", string(ts[""].FixAt(0)))
+	assertEqual(t, "c   d", string(ts["sub"].FixAt(0)))
+}
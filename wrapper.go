@@ -0,0 +1,130 @@
+// Template engine that only has named placeholders – nothing more!
+// Copyright (C) 2017-2018 Marcus Perlick
+package goxic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	wrappersLock sync.RWMutex
+	wrappers     = make(map[string]CntWrapper)
+)
+
+func init() {
+	RegisterWrapper("trim", XformWrapper(bytes.TrimSpace))
+	RegisterWrapper("upper", XformWrapper(bytes.ToUpper))
+	RegisterWrapper("lower", XformWrapper(bytes.ToLower))
+}
+
+// RegisterWrapper makes a CntWrapper available under the given name. Only
+// wrappers that are attached to a template by their registered name can be
+// restored when a serialized template is loaded. Registered wrappers can also
+// be attached to placeholders from the template source, see
+// Parser.WrapSep. RegisterWrapper panics if name is empty, wrapper is nil or
+// the name is already registered.
+func RegisterWrapper(name string, wrapper CntWrapper) {
+	if len(name) == 0 {
+		panic("goxic: register wrapper with empty name")
+	}
+	if wrapper == nil {
+		panic("goxic: register nil wrapper '" + name + "'")
+	}
+	wrappersLock.Lock()
+	defer wrappersLock.Unlock()
+	if _, dup := wrappers[name]; dup {
+		panic("goxic: wrapper '" + name + "' registered twice")
+	}
+	wrappers[name] = wrapper
+}
+
+// LookupWrapper returns the CntWrapper registered with name or nil if there
+// is no such wrapper.
+func LookupWrapper(name string) CntWrapper {
+	wrappersLock.RLock()
+	defer wrappersLock.RUnlock()
+	return wrappers[name]
+}
+
+// WrapName attaches the wrapper that was registered under name to the
+// placeholders at the positions idxs. Other than Wrap the template remembers
+// the name of the wrapper.
+func (t *Template) WrapName(name string, idxs ...int) error {
+	return t.WrapNames([]string{name}, idxs...)
+}
+
+// WrapNames attaches a chain of registered wrappers to the placeholders at
+// the positions idxs. The wrappers are applied in the given order, i.e. the
+// first wrapper gets the bound content and the last wrapper's result is
+// emitted.
+func (t *Template) WrapNames(names []string, idxs ...int) error {
+	chain := make([]CntWrapper, len(names))
+	for i, nm := range names {
+		if chain[i] = LookupWrapper(nm); chain[i] == nil {
+			return fmt.Errorf("no wrapper '%s'", nm)
+		}
+	}
+	var wrapper CntWrapper
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		wrapper = chain[0]
+	default:
+		wrapper = func(cnt Content) Content {
+			for _, w := range chain {
+				cnt = w(cnt)
+			}
+			return cnt
+		}
+	}
+	t.Wrap(wrapper, idxs...)
+	for _, idx := range idxs {
+		t.wrapNm[idx] = names
+	}
+	return nil
+}
+
+// WrapNamesAt returns the registered names of the wrappers at placeholder
+// position idx. It returns nil if there is no wrapper or if the wrapper was
+// not attached by name.
+func (t *Template) WrapNamesAt(idx int) []string {
+	if idx < 0 || idx >= len(t.wrapNm) {
+		return nil
+	}
+	return t.wrapNm[idx]
+}
+
+type xformCnt struct {
+	cnt   Content
+	xform func([]byte) []byte
+}
+
+func (x xformCnt) Emit(wr io.Writer) int {
+	return x.EmitCtx(context.Background(), wr)
+}
+
+func (x xformCnt) EmitCtx(ctx context.Context, wr io.Writer) int {
+	var buf bytes.Buffer
+	EmitCtx(ctx, x.cnt, &buf)
+	n, err := wr.Write(x.xform(buf.Bytes()))
+	if err != nil {
+		panic(EmitError{n, err})
+	}
+	return n
+}
+
+func (x xformCnt) Prestart(ctx context.Context) { Prestart(ctx, x.cnt) }
+
+// XformWrapper creates a CntWrapper that emits the wrapped content into a
+// buffer and writes the result of xform applied to the buffered bytes.
+// Flush points in the wrapped content have no effect.
+func XformWrapper(xform func([]byte) []byte) CntWrapper {
+	return func(cnt Content) Content {
+		return xformCnt{cnt, xform}
+	}
+}